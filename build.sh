@@ -71,6 +71,7 @@ option go_package = "github.com/ssotops/gitspace-plugin-sdk/proto";
 message PluginInfo {
     string name = 1;
     string version = 2;
+    string error_message = 3;
 }
 
 message PluginInfoRequest {}
@@ -78,12 +79,14 @@ message PluginInfoRequest {}
 message CommandRequest {
     string command = 1;
     map<string, string> parameters = 2;
+    string request_id = 3;
 }
 
 message CommandResponse {
     bool success = 1;
     string result = 2;
     string error_message = 3;
+    string request_id = 4;
 }
 
 message MenuRequest {}
@@ -95,12 +98,105 @@ message MenuItem {
 
 message MenuResponse {
     bytes menu_data = 1;
+    string error_message = 2;
+}
+
+message SubscriptionRequest {}
+
+message SubscriptionResponse {
+    repeated string events = 1;
+    string error_message = 2;
+}
+
+message Event {
+    string id = 1;
+    string type = 2;
+    string repository = 3;
+    map<string, string> attributes = 4;
+    int64 timestamp = 5;
+}
+
+message EventResponse {
+    bool success = 1;
+    string error_message = 2;
+}
+
+message ConfigRequest {}
+
+message ConfigResponse {
+    bytes schema_data = 1;
+    map<string, string> values = 2;
+    string error_message = 3;
+}
+
+message UpdateConfigRequest {
+    map<string, string> values = 1;
+}
+
+message UpdateConfigResponse {
+    bool success = 1;
+    string error_message = 2;
+}
+
+message SecretsRequest {
+    map<string, string> values = 1;
+}
+
+message SecretsResponse {
+    bool success = 1;
+    string error_message = 2;
+}
+
+message HostCallRequest {
+    string method = 1;
+    map<string, string> parameters = 2;
+}
+
+message HostCallResponse {
+    bool success = 1;
+    bytes result = 2;
+    string error_message = 3;
+}
+
+message Chunk {
+    string request_id = 1;
+    uint64 sequence = 2;
+    bytes data = 3;
+    bool final = 4;
+    string checksum = 5;
+}
+
+message ChunkAck {
+    string request_id = 1;
+    uint64 sequence = 2;
+}
+
+message LogAttr {
+    string key = 1;
+    string value = 2;
+}
+
+// LogRecord carries a plugin log record to the host. Level uses log/slog's
+// values: -4 debug, 0 info, 4 warn, 8 error.
+message LogRecord {
+    int64 time_unix_nano = 1;
+    int32 level = 2;
+    string message = 3;
+    repeated LogAttr attrs = 4;
+    string request_id = 5;
+    string plugin = 6;
+    string caller = 7;
 }
 
 service PluginService {
     rpc GetPluginInfo(PluginInfoRequest) returns (PluginInfo) {}
     rpc ExecuteCommand(CommandRequest) returns (CommandResponse) {}
     rpc GetMenu(MenuRequest) returns (MenuResponse) {}
+    rpc GetSubscriptions(SubscriptionRequest) returns (SubscriptionResponse) {}
+    rpc HandleEvent(Event) returns (EventResponse) {}
+    rpc GetConfig(ConfigRequest) returns (ConfigResponse) {}
+    rpc UpdateConfig(UpdateConfigRequest) returns (UpdateConfigResponse) {}
+    rpc SetSecrets(SecretsRequest) returns (SecretsResponse) {}
 }
 EOL
 
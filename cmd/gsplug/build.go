@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	"github.com/ssotops/gitspace-plugin-sdk/host/dev"
+)
+
+// buildFlags are the flags shared by build and install.
+type buildFlags struct {
+	targets string
+	outDir  string
+}
+
+func (f *buildFlags) register(set *flag.FlagSet) {
+	set.StringVar(&f.targets, "target", "", "comma-separated os/arch pairs to build for (default: the manifest's platforms, or this platform)")
+	set.StringVar(&f.outDir, "out", "", "directory for built binaries (default: <dir>/dist)")
+}
+
+func (f *buildFlags) options() (dev.BuildOptions, error) {
+	opts := dev.BuildOptions{OutDir: f.outDir, Stderr: os.Stderr}
+	for _, s := range strings.Split(f.targets, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		target, err := gsplug.ParsePlatform(s)
+		if err != nil {
+			return opts, err
+		}
+		opts.Targets = append(opts.Targets, target)
+	}
+	return opts, nil
+}
+
+// buildPlugin builds the plugin in the single optional positional argument.
+func buildPlugin(name string, set *flag.FlagSet, flags *buildFlags, args []string) (*dev.BuildResult, error) {
+	positional, err := parseArgs(set, args)
+	if err != nil {
+		return nil, err
+	}
+	dir := "."
+	switch len(positional) {
+	case 0:
+	case 1:
+		dir = positional[0]
+	default:
+		return nil, fmt.Errorf("usage: gsplug %s [dir]", name)
+	}
+
+	opts, err := flags.options()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := dev.BuildPlugin(ctx, dir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]gsplug.Platform, 0, len(result.Binaries))
+	for target := range result.Binaries {
+		targets = append(targets, target)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].String() < targets[j].String() })
+	for _, target := range targets {
+		fmt.Println(dimStyle.Render(fmt.Sprintf("  built %s %s", target, result.Binaries[target])))
+	}
+	fmt.Println(successStyle.Render(fmt.Sprintf("✓ Built %s %s", result.Manifest.Metadata.Name, result.Manifest.Metadata.Version)))
+	return result, nil
+}
+
+func runBuild(args []string) error {
+	set := flag.NewFlagSet("build", flag.ContinueOnError)
+	var flags buildFlags
+	flags.register(set)
+	_, err := buildPlugin("build", set, &flags, args)
+	return err
+}
+
+func runInstall(args []string) error {
+	set := flag.NewFlagSet("install", flag.ContinueOnError)
+	var flags buildFlags
+	flags.register(set)
+	pluginsDir := set.String("plugins-dir", "", "install into this directory instead of Gitspace's plugins directory")
+	result, err := buildPlugin("install", set, &flags, args)
+	if err != nil {
+		return err
+	}
+
+	if *pluginsDir == "" {
+		if *pluginsDir, err = gsplug.GetPluginsDir(); err != nil {
+			return err
+		}
+	}
+	installed, err := dev.Install(result, *pluginsDir)
+	if err != nil {
+		return err
+	}
+	fmt.Println(successStyle.Render("✓ Installed " + result.Manifest.Metadata.Name + " to " + installed))
+	return nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/ssotops/gitspace-plugin-sdk/host"
+	"github.com/ssotops/gitspace-plugin-sdk/host/dev"
+)
+
+func runDev(args []string) error {
+	set := flag.NewFlagSet("dev", flag.ContinueOnError)
+	var flags pluginFlags
+	flags.register(set)
+	link := set.Bool("link", false, "link the working copy into the plugins directory")
+	positional, err := parseArgs(set, args)
+	if err != nil {
+		return err
+	}
+	dir := "."
+	switch len(positional) {
+	case 0:
+	case 1:
+		dir = positional[0]
+	default:
+		return fmt.Errorf("usage: gsplug dev [dir] [--link]")
+	}
+
+	session, err := dev.NewSession(dir, flags.options())
+	if err != nil {
+		return err
+	}
+
+	if *link {
+		path, err := dev.Link(session.Dir, session.Name())
+		if err != nil {
+			return err
+		}
+		fmt.Println(dimStyle.Render("Linked " + path + " → " + session.Dir))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Println(dimStyle.Render("Building " + session.Name() + "..."))
+	client, err := session.Start(ctx)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	fmt.Println(successStyle.Render("✓ " + session.Name() + " is running; watching " + session.Dir))
+	printDevHelp()
+
+	events := make(chan dev.Event)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- session.Watch(ctx, events)
+	}()
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-watchErr:
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		case event := <-events:
+			printDevEvent(event)
+		case line, ok := <-lines:
+			if !ok {
+				return nil
+			}
+			quit, err := devCommand(ctx, session, client, line)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, errorStyle.Render("✗ "+err.Error()))
+			}
+			if quit {
+				return nil
+			}
+		}
+	}
+}
+
+func printDevHelp() {
+	fmt.Println(dimStyle.Render("Commands: info, menu, exec <command> [name=value ...], reload, quit"))
+}
+
+func printDevEvent(event dev.Event) {
+	switch event.Kind {
+	case dev.EventReloaded:
+		fmt.Println(successStyle.Render(fmt.Sprintf("✓ Rebuilt and reloaded in %s (%s)",
+			event.Duration.Round(time.Millisecond), strings.Join(event.Changed, ", "))))
+	case dev.EventBuildFailed:
+		var buildErr *dev.BuildError
+		if errors.As(event.Err, &buildErr) {
+			fmt.Println(errorStyle.Render("✗ Build failed; the previous version keeps running"))
+			fmt.Println(buildErr.Output)
+			return
+		}
+		fmt.Println(errorStyle.Render("✗ " + event.Err.Error()))
+	case dev.EventReloadFailed:
+		fmt.Println(errorStyle.Render("✗ Reload failed: " + event.Err.Error()))
+	}
+}
+
+// devCommand runs one line typed at the dev prompt and reports whether the
+// session should end.
+func devCommand(ctx context.Context, session *dev.Session, client *host.Client, line string) (bool, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false, nil
+	}
+
+	switch fields[0] {
+	case "quit", "exit":
+		return true, nil
+	case "help":
+		printDevHelp()
+	case "info":
+		info, err := client.GetPluginInfo()
+		if err != nil {
+			return false, err
+		}
+		fmt.Printf("%s %s\n", titleStyle.Render(info.Name), info.Version)
+	case "menu":
+		menu, err := client.GetMenu()
+		if err != nil {
+			return false, err
+		}
+		printMenu(os.Stdout, menu, "")
+	case "exec":
+		if len(fields) < 2 {
+			return false, fmt.Errorf("usage: exec <command> [name=value ...]")
+		}
+		params := paramFlag{}
+		for _, pair := range fields[2:] {
+			if err := params.Set(pair); err != nil {
+				return false, err
+			}
+		}
+		return false, executeCommand(client, fields[1], params)
+	case "reload":
+		if err := session.Rebuild(ctx); err != nil {
+			kind := dev.EventReloadFailed
+			if errors.As(err, new(*dev.BuildError)) {
+				kind = dev.EventBuildFailed
+			}
+			printDevEvent(dev.Event{Kind: kind, Err: err})
+			return false, nil
+		}
+		fmt.Println(successStyle.Render("✓ Reloaded"))
+	default:
+		return false, fmt.Errorf("unknown command %q", fields[0])
+	}
+	return false, nil
+}
+
+func runLink(args []string) error {
+	set := flag.NewFlagSet("link", flag.ContinueOnError)
+	positional, err := parseArgs(set, args)
+	if err != nil {
+		return err
+	}
+	dir := "."
+	if len(positional) > 0 {
+		dir = positional[0]
+	}
+
+	session, err := dev.NewSession(dir, host.Options{})
+	if err != nil {
+		return err
+	}
+	path, err := dev.Link(session.Dir, session.Name())
+	if err != nil {
+		return err
+	}
+	fmt.Println(successStyle.Render("✓ Linked " + path + " → " + session.Dir))
+	if _, err := os.Stat(session.Binary()); err != nil {
+		fmt.Println(dimStyle.Render("Build the plugin to " + session.Binary() + " or run gsplug dev to keep it built"))
+	}
+	return nil
+}
+
+func runUnlink(args []string) error {
+	set := flag.NewFlagSet("unlink", flag.ContinueOnError)
+	positional, err := parseArgs(set, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: gsplug unlink <name>")
+	}
+	if err := dev.Unlink(positional[0]); err != nil {
+		return err
+	}
+	fmt.Println(successStyle.Render("✓ Unlinked " + positional[0]))
+	return nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/ssotops/gitspace-plugin-sdk/explorer"
+)
+
+func runExplore(args []string) error {
+	set := flag.NewFlagSet("explore", flag.ContinueOnError)
+	var flags pluginFlags
+	flags.register(set)
+	positional, err := parseArgs(set, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: gsplug explore <plugin>")
+	}
+
+	client, err := startPlugin(positional[0], &flags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	e, err := explorer.New(client)
+	if err != nil {
+		return err
+	}
+	return e.Run()
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/ssotops/gitspace-plugin-sdk/logger"
+)
+
+func runLogs(args []string) error {
+	set := flag.NewFlagSet("logs", flag.ContinueOnError)
+	level := set.String("level", "", "show records at this level and above: debug, info, warn, error")
+	since := set.String("since", "", "show records after a time (RFC 3339 or 2006-01-02 15:04:05) or a duration ago (1h)")
+	until := set.String("until", "", "show records before a time or a duration ago")
+	requestID := set.String("request", "", "show the records of one request ID")
+	where := paramFlag{}
+	set.Var(where, "where", "show records with an attribute value, as key=value (repeatable)")
+	follow := set.Bool("follow", false, "keep printing records as they are written")
+	set.BoolVar(follow, "f", false, "shorthand for --follow")
+	dir := set.String("dir", "", "log directory (default: ~/.ssot/gitspace/logs)")
+	jsonOutput := set.Bool("json", false, "print records as JSON lines")
+	plugins, err := parseArgs(set, args)
+	if err != nil {
+		return err
+	}
+
+	query := logger.Query{Dir: *dir, Plugins: plugins, RequestID: *requestID}
+	if len(where) > 0 {
+		query.Attrs = where
+	}
+	if *level != "" {
+		min, err := log.ParseLevel(*level)
+		if err != nil {
+			return err
+		}
+		query.Levels = logger.AtLeast(min)
+	}
+	if query.Since, err = parseTime(*since); err != nil {
+		return err
+	}
+	if query.Until, err = parseTime(*until); err != nil {
+		return err
+	}
+
+	emit := func(entry logger.Entry) error {
+		if *jsonOutput {
+			line, err := logger.EncodeEntry(logger.FormatJSON, entry)
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(line)
+			return err
+		}
+		_, err := fmt.Println(logger.RenderEntry(entry))
+		return err
+	}
+
+	entries, position, err := logger.SearchPosition(query)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := emit(entry); err != nil {
+			return err
+		}
+	}
+	if !*follow {
+		if len(entries) == 0 && !*jsonOutput {
+			fmt.Fprintln(os.Stderr, dimStyle.Render("No matching log records."))
+		}
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err = logger.FollowFrom(ctx, query, position, 0, emit)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// parseTime accepts an absolute time or a duration before now. Empty
+// returns the zero time.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected RFC 3339, 2006-01-02 15:04:05 or a duration such as 1h", s)
+}
@@ -0,0 +1,187 @@
+// Command gsplug runs and inspects Gitspace plugins without Gitspace.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	"github.com/ssotops/gitspace-plugin-sdk/host"
+)
+
+var (
+	titleStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FAFAFA")).
+			Background(lipgloss.Color("#874BFD")).
+			Padding(0, 1)
+
+	labelStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#B4BEFE"))
+
+	commandStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#F9E2AF"))
+
+	dimStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#6C7086"))
+
+	successStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#A6E3A1"))
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#F38BA8"))
+)
+
+type subcommand struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var subcommands []subcommand
+
+func init() {
+	subcommands = []subcommand{
+		{"info", "info <plugin>", runInfo},
+		{"menu", "menu <plugin>", runMenu},
+		{"exec", "exec <plugin> <command> [--param name=value ...]", runExec},
+		{"explore", "explore <plugin>", runExplore},
+		{"new", "new <name> [--template minimal|router|events|grpc] [--module path]", runNew},
+		{"build", "build [dir] [--target os/arch,...]", runBuild},
+		{"install", "install [dir] [--plugins-dir dir]", runInstall},
+		{"dev", "dev [dir] [--link]", runDev},
+		{"link", "link [dir]", runLink},
+		{"unlink", "unlink <name>", runUnlink},
+		{"logs", "logs [plugin...] [--level warn] [--since 1h] [--request id] [--where key=value] [--follow]", runLogs},
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: gsplug <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	for _, sub := range subcommands {
+		fmt.Fprintf(os.Stderr, "  gsplug %s\n", sub.usage)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "<plugin> is a path to a plugin binary or the name of an installed plugin.")
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, sub := range subcommands {
+		if sub.name != os.Args[1] {
+			continue
+		}
+		if err := sub.run(os.Args[2:]); err != nil {
+			if !errors.Is(err, flag.ErrHelp) {
+				fmt.Fprintln(os.Stderr, errorStyle.Render("✗ "+err.Error()))
+			}
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "gsplug: unknown command %q\n\n", os.Args[1])
+	usage()
+	os.Exit(2)
+}
+
+// parseArgs parses fs from args, allowing flags after positional arguments,
+// and returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// pluginFlags are the flags shared by subcommands that launch a plugin.
+type pluginFlags struct {
+	timeout time.Duration
+	codec   string
+	logs    bool
+}
+
+func (f *pluginFlags) register(fs *flag.FlagSet) {
+	fs.DurationVar(&f.timeout, "timeout", 0, "fail requests that take longer than this (default 5m, negative disables)")
+	fs.StringVar(&f.codec, "codec", string(gsplug.CodecProtobuf), "wire codec: protobuf or json")
+	fs.BoolVar(&f.logs, "logs", false, "print the plugin's forwarded log records and each command's start and end")
+}
+
+func (f *pluginFlags) options() host.Options {
+	opts := host.Options{
+		Stderr: os.Stderr,
+		Codec:  gsplug.CodecName(f.codec),
+		Limits: host.Limits{RequestTimeout: f.timeout},
+	}
+	if f.logs {
+		logs := log.NewWithOptions(os.Stderr, log.Options{
+			ReportTimestamp: true,
+			Level:           log.DebugLevel,
+		})
+		opts.Logs = logs
+		opts.Logger = slog.New(logs)
+	}
+	return opts
+}
+
+// resolvePlugin returns the binary for arg, which is a path to a binary or
+// plugin directory, or the name of a plugin installed in the plugins
+// directory. Plugin directories resolve to the binary for this platform.
+func resolvePlugin(arg string) (string, error) {
+	if info, err := os.Stat(arg); err == nil {
+		if info.IsDir() {
+			return host.ResolveBinary(arg)
+		}
+		if !strings.ContainsRune(arg, filepath.Separator) {
+			return "./" + arg, nil
+		}
+		return arg, nil
+	}
+	if strings.ContainsRune(arg, filepath.Separator) {
+		return arg, nil
+	}
+
+	pluginsDir, err := gsplug.GetPluginsDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(pluginsDir, arg)
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("plugin %q is neither a file nor installed in %s", arg, pluginsDir)
+	}
+	return host.ResolveBinary(dir)
+}
+
+// startPlugin launches the plugin named by arg.
+func startPlugin(arg string, flags *pluginFlags) (*host.Client, error) {
+	binary, err := resolvePlugin(arg)
+	if err != nil {
+		return nil, err
+	}
+	client, err := host.Start(binary, flags.options())
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// sdkModule is the module path generated projects require.
+const sdkModule = "github.com/ssotops/gitspace-plugin-sdk"
+
+// localSDKVersion is the placeholder version required alongside a replace
+// directive for a local SDK checkout.
+const localSDKVersion = "v0.0.0-00010101000000-000000000000"
+
+//go:embed templates
+var templates embed.FS
+
+// projectTemplates lists the templates gsplug new can generate.
+var projectTemplates = []string{"minimal", "router", "events", "grpc"}
+
+var pluginNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+type projectData struct {
+	Name        string
+	Type        string
+	Module      string
+	Description string
+	Template    string
+	SDKPath     string
+	SDKVersion  string
+}
+
+// templateFuncs are available to every project template.
+var templateFuncs = template.FuncMap{"toml": tomlString}
+
+func runNew(args []string) error {
+	set := flag.NewFlagSet("new", flag.ContinueOnError)
+	templateName := set.String("template", "minimal", "project template: "+strings.Join(projectTemplates, ", "))
+	module := set.String("module", "", "module path (default: the plugin name)")
+	dir := set.String("dir", "", "output directory (default: ./<name>)")
+	sdk := set.String("sdk", "", "path to a local SDK checkout to use through a replace directive")
+	description := set.String("description", "", "description for the manifest")
+	positional, err := parseArgs(set, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: gsplug new <name> [--template %s]", strings.Join(projectTemplates, "|"))
+	}
+
+	name := positional[0]
+	if !pluginNamePattern.MatchString(name) {
+		return fmt.Errorf("plugin name %q must be lowercase letters, digits and dashes", name)
+	}
+	if !contains(projectTemplates, *templateName) {
+		return fmt.Errorf("unknown template %q, choose one of %s", *templateName, strings.Join(projectTemplates, ", "))
+	}
+
+	data := projectData{
+		Name:        name,
+		Type:        typeName(name),
+		Module:      *module,
+		Description: *description,
+		Template:    *templateName,
+	}
+	if data.Module == "" {
+		data.Module = name
+	}
+	if data.Description == "" {
+		data.Description = "A Gitspace plugin"
+	}
+
+	outDir := *dir
+	if outDir == "" {
+		outDir = name
+	}
+	if *sdk != "" {
+		data.SDKPath, err = relativeTo(outDir, *sdk)
+		if err != nil {
+			return err
+		}
+		data.SDKVersion = localSDKVersion
+	} else if data.SDKVersion = sdkVersion(); data.SDKVersion == "" {
+		return fmt.Errorf("gsplug was built from source and cannot tell which %s version to require; pass --sdk with the path of the SDK checkout", sdkModule)
+	}
+
+	if entries, err := os.ReadDir(outDir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s already exists and is not empty", outDir)
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", outDir, err)
+	}
+
+	files, err := renderProject(data)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		target := filepath.Join(outDir, file.name)
+		if err := os.WriteFile(target, file.content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		fmt.Println(dimStyle.Render("  created " + target))
+	}
+
+	fmt.Println(successStyle.Render(fmt.Sprintf("✓ Created %s plugin %s", data.Template, name)))
+	fmt.Println()
+	fmt.Println(labelStyle.Render("Next steps:"))
+	fmt.Printf("  cd %s\n", outDir)
+	fmt.Println("  go mod tidy")
+	fmt.Println("  make test")
+	fmt.Printf("  make build && gsplug exec ./%s greet\n", name)
+	return nil
+}
+
+type projectFile struct {
+	name    string
+	content []byte
+}
+
+// renderProject renders the common templates and those of data.Template.
+func renderProject(data projectData) ([]projectFile, error) {
+	var files []projectFile
+	for _, dir := range []string{"common", data.Template} {
+		root := path.Join("templates", dir)
+		entries, err := fs.ReadDir(templates, root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", dir, err)
+		}
+
+		for _, entry := range entries {
+			source := path.Join(root, entry.Name())
+			tmpl, err := template.New(entry.Name()).Funcs(templateFuncs).ParseFS(templates, source)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse template %s: %w", source, err)
+			}
+
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, data); err != nil {
+				return nil, fmt.Errorf("failed to render template %s: %w", source, err)
+			}
+
+			name := strings.TrimSuffix(entry.Name(), ".tmpl")
+			if name == "gitignore" {
+				name = ".gitignore"
+			}
+			content := buf.Bytes()
+			if strings.HasSuffix(name, ".go") {
+				if content, err = format.Source(content); err != nil {
+					return nil, fmt.Errorf("failed to format %s: %w", name, err)
+				}
+			}
+			files = append(files, projectFile{name: name, content: content})
+		}
+	}
+	return files, nil
+}
+
+// sdkVersion returns the SDK version gsplug was installed from, which the
+// generated go.mod requires so the project builds against the same SDK as
+// its templates. It is empty when gsplug was built from a checkout.
+func sdkVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Path != sdkModule || info.Main.Version == "(devel)" {
+		return ""
+	}
+	return info.Main.Version
+}
+
+// tomlString quotes s as a TOML basic string.
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if unicode.IsControl(r) {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// typeName turns a plugin name such as "repo-stats" into a Go type name
+// such as "RepoStatsPlugin".
+func typeName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "-") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	if !strings.HasSuffix(b.String(), "Plugin") {
+		b.WriteString("Plugin")
+	}
+	return b.String()
+}
+
+// relativeTo returns target as a path relative to dir, as needed by a
+// replace directive in dir's go.mod.
+func relativeTo(dir, target string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absDir, absTarget)
+	if err != nil {
+		return absTarget, nil
+	}
+	if !strings.HasPrefix(rel, ".") {
+		rel = "./" + rel
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
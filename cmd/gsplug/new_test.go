@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func renderFile(t *testing.T, data projectData, name string) string {
+	t.Helper()
+	files, err := renderProject(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if file.name == name {
+			return string(file.content)
+		}
+	}
+	t.Fatalf("project has no %s", name)
+	return ""
+}
+
+func TestRenderManifestEscapes(t *testing.T) {
+	description := "Say \"hi\" \\ twice\n\tthen\x01 leave"
+	data := projectData{Name: "greeter", Type: "GreeterPlugin", Module: "greeter", Description: description, Template: "minimal", SDKVersion: "v1.2.3"}
+
+	var manifest struct {
+		Metadata struct {
+			Name        string
+			Description string
+		}
+	}
+	if _, err := toml.Decode(renderFile(t, data, "gitspace-plugin.toml"), &manifest); err != nil {
+		t.Fatalf("generated manifest does not parse: %v", err)
+	}
+	if manifest.Metadata.Name != "greeter" || manifest.Metadata.Description != description {
+		t.Fatalf("manifest metadata = %+v", manifest.Metadata)
+	}
+}
+
+func TestRenderGoMod(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    projectData
+		want    []string
+		notWant string
+	}{
+		{
+			name:    "released sdk",
+			data:    projectData{SDKVersion: "v1.2.3"},
+			want:    []string{"require " + sdkModule + " v1.2.3"},
+			notWant: "replace",
+		},
+		{
+			name: "local sdk",
+			data: projectData{SDKVersion: localSDKVersion, SDKPath: "../sdk"},
+			want: []string{"require " + sdkModule + " " + localSDKVersion, "replace " + sdkModule + " => ../sdk"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.data
+			data.Name, data.Type, data.Module, data.Template = "greeter", "GreeterPlugin", "example.com/greeter", "minimal"
+			goMod := renderFile(t, data, "go.mod")
+			for _, want := range tt.want {
+				if !strings.Contains(goMod, want) {
+					t.Errorf("go.mod lacks %q:\n%s", want, goMod)
+				}
+			}
+			if tt.notWant != "" && strings.Contains(goMod, tt.notWant) {
+				t.Errorf("go.mod contains %q:\n%s", tt.notWant, goMod)
+			}
+		})
+	}
+}
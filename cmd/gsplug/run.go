@@ -0,0 +1,357 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	"github.com/ssotops/gitspace-plugin-sdk/host"
+)
+
+func runInfo(args []string) error {
+	set := flag.NewFlagSet("info", flag.ContinueOnError)
+	var flags pluginFlags
+	flags.register(set)
+	positional, err := parseArgs(set, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: gsplug info <plugin>")
+	}
+
+	client, err := startPlugin(positional[0], &flags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	info, err := client.GetPluginInfo()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(titleStyle.Render(info.Name))
+	fmt.Printf("%s %s\n", labelStyle.Render("Version:"), info.Version)
+	fmt.Printf("%s %s\n", labelStyle.Render("Binary: "), client.Binary())
+
+	// Details the protocol does not carry come from the manifest shipped
+	// with the binary, when there is one.
+	manifestPath := filepath.Join(pluginDir(client.Binary()), gsplug.ManifestFileName)
+	manifest, err := gsplug.LoadManifest(manifestPath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, errorStyle.Render(err.Error()))
+		}
+		return nil
+	}
+	if manifest.Metadata.Description != "" {
+		fmt.Printf("%s %s\n", labelStyle.Render("About:  "), manifest.Metadata.Description)
+	}
+	if len(manifest.Platforms) > 0 {
+		names := make([]string, len(manifest.Platforms))
+		for i, platform := range manifest.Platforms {
+			names[i] = platform.String()
+		}
+		fmt.Printf("%s %s\n", labelStyle.Render("Targets:"), strings.Join(names, ", "))
+	}
+	if len(manifest.Events.Subscribe) > 0 {
+		names := make([]string, len(manifest.Events.Subscribe))
+		for i, event := range manifest.Events.Subscribe {
+			names[i] = string(event)
+		}
+		fmt.Printf("%s %s\n", labelStyle.Render("Events: "), strings.Join(names, ", "))
+	}
+	if !manifest.Permissions.IsEmpty() {
+		fmt.Println(labelStyle.Render("Permissions:"))
+		for _, line := range manifest.Permissions.Describe() {
+			fmt.Println("  " + line)
+		}
+	}
+	return nil
+}
+
+// pluginDir returns the directory holding the manifest for binary, which
+// is either next to it or, for a multi-platform plugin, above bin/<os>_<arch>.
+func pluginDir(binary string) string {
+	dir := filepath.Dir(binary)
+	if filepath.Base(filepath.Dir(dir)) == gsplug.PlatformsDir {
+		return filepath.Dir(filepath.Dir(dir))
+	}
+	return dir
+}
+
+func runMenu(args []string) error {
+	set := flag.NewFlagSet("menu", flag.ContinueOnError)
+	var flags pluginFlags
+	flags.register(set)
+	positional, err := parseArgs(set, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: gsplug menu <plugin>")
+	}
+
+	client, err := startPlugin(positional[0], &flags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	menu, err := client.GetMenu()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(titleStyle.Render(client.Name()))
+	printMenu(os.Stdout, menu, "")
+	return nil
+}
+
+// printMenu renders a menu as a tree with each option's command and
+// parameters.
+func printMenu(w io.Writer, menu []gsplug.MenuOption, indent string) {
+	for i, option := range menu {
+		branch, childIndent := "├── ", indent+"│   "
+		if i == len(menu)-1 {
+			branch, childIndent = "└── ", indent+"    "
+		}
+
+		line := indent + branch + option.Label
+		if option.Command != "" {
+			line += " " + commandStyle.Render(option.Command)
+		}
+		fmt.Fprintln(w, line)
+
+		for _, param := range option.Parameters {
+			fmt.Fprintln(w, childIndent+dimStyle.Render(describeParameter(param)))
+		}
+		printMenu(w, option.SubMenu, childIndent)
+	}
+}
+
+func describeParameter(param gsplug.ParameterInfo) string {
+	s := "--param " + param.Name + "=<" + string(param.Type)
+	if param.Type == "" {
+		s += string(gsplug.ParameterString)
+	}
+	s += ">"
+	if param.Required {
+		s += " (required)"
+	}
+	if param.Default != "" {
+		s += " [default " + param.Default + "]"
+	}
+	if param.Description != "" {
+		s += "  " + param.Description
+	}
+	return s
+}
+
+// paramFlag collects repeated --param name=value flags.
+type paramFlag map[string]string
+
+func (p paramFlag) String() string {
+	pairs := make([]string, 0, len(p))
+	for name, value := range p {
+		pairs = append(pairs, name+"="+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (p paramFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected name=value, got %q", s)
+	}
+	p[name] = value
+	return nil
+}
+
+func runExec(args []string) error {
+	set := flag.NewFlagSet("exec", flag.ContinueOnError)
+	var flags pluginFlags
+	flags.register(set)
+	params := paramFlag{}
+	set.Var(params, "param", "command parameter as name=value (repeatable)")
+	positional, err := parseArgs(set, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("usage: gsplug exec <plugin> <command> [--param name=value ...]")
+	}
+	command := positional[1]
+
+	client, err := startPlugin(positional[0], &flags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return executeCommand(client, command, params)
+}
+
+// executeCommand checks params against the command's menu entry, runs it and
+// prints the result. Streamed data goes to stdout as it arrives.
+func executeCommand(client *host.Client, command string, params map[string]string) error {
+	menu, err := client.GetMenu()
+	if err != nil {
+		return err
+	}
+	if option := findCommand(menu, command); option != nil {
+		if err := checkParameters(option.Parameters, params); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintln(os.Stderr, dimStyle.Render("command "+command+" is not in the plugin's menu"))
+	}
+
+	progress := newProgress(command)
+	start := time.Now()
+	response, err := client.ExecuteCommandStream(command, params, progress.track(os.Stdout))
+	progress.stop()
+	if err != nil {
+		return err
+	}
+
+	if response.Result != "" {
+		fmt.Print(response.Result)
+		if !strings.HasSuffix(response.Result, "\n") {
+			fmt.Println()
+		}
+	}
+	if !response.Success {
+		return fmt.Errorf("%s failed: %s", command, response.ErrorMessage)
+	}
+	fmt.Fprintln(os.Stderr, successStyle.Render(fmt.Sprintf("✓ %s finished in %s", command, time.Since(start).Round(time.Millisecond))))
+	return nil
+}
+
+// findCommand returns the menu option that runs command, searching sub-menus.
+func findCommand(menu []gsplug.MenuOption, command string) *gsplug.MenuOption {
+	for i := range menu {
+		if menu[i].Command == command {
+			return &menu[i]
+		}
+		if option := findCommand(menu[i].SubMenu, command); option != nil {
+			return option
+		}
+	}
+	return nil
+}
+
+// checkParameters fills in defaults and validates params against the
+// command's declared parameters.
+func checkParameters(declared []gsplug.ParameterInfo, params map[string]string) error {
+	for _, param := range declared {
+		value, ok := params[param.Name]
+		if !ok && param.Default != "" {
+			params[param.Name] = param.Default
+			continue
+		}
+		if !ok {
+			if param.Required {
+				return fmt.Errorf("missing required parameter %q", param.Name)
+			}
+			continue
+		}
+		if err := param.Validate(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// progress shows a spinner with the amount of streamed data on stderr while
+// a command runs. It stays silent when stderr is not a terminal.
+type progress struct {
+	command string
+	enabled bool
+	mu      sync.Mutex
+	bytes   int64
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+func newProgress(command string) *progress {
+	p := &progress{
+		command: command,
+		enabled: isatty.IsTerminal(os.Stderr.Fd()),
+		done:    make(chan struct{}),
+	}
+	if p.enabled {
+		p.wg.Add(1)
+		go p.spin()
+	}
+	return p
+}
+
+func (p *progress) spin() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for frame := 0; ; frame++ {
+		select {
+		case <-p.done:
+			fmt.Fprint(os.Stderr, "\r\033[K")
+			return
+		case <-ticker.C:
+		}
+
+		p.mu.Lock()
+		received := p.bytes
+		p.mu.Unlock()
+
+		status := "Running " + p.command
+		if received > 0 {
+			status += fmt.Sprintf(" (%s received)", formatBytes(received))
+		}
+		fmt.Fprintf(os.Stderr, "\r\033[K%s %s", commandStyle.Render(spinnerFrames[frame%len(spinnerFrames)]), status)
+	}
+}
+
+// track returns a writer that forwards streamed data to w and counts it.
+func (p *progress) track(w io.Writer) io.Writer {
+	return writerFunc(func(b []byte) (int, error) {
+		p.mu.Lock()
+		p.bytes += int64(len(b))
+		p.mu.Unlock()
+		return w.Write(b)
+	})
+}
+
+func (p *progress) stop() {
+	close(p.done)
+	p.wg.Wait()
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(b []byte) (int, error) {
+	return f(b)
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
@@ -3,15 +3,16 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 
 	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
 	"github.com/ssotops/gitspace-plugin-sdk/logger"
 	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
-	"google.golang.org/protobuf/proto"
 )
 
+// version is set from gitspace-plugin.toml by gsplug build.
+var version = "1.0.0"
+
 type HelloWorldPlugin struct {
 	logger *logger.RateLimitedLogger
 }
@@ -20,10 +21,13 @@ func (p *HelloWorldPlugin) GetPluginInfo(req *pb.PluginInfoRequest) (*pb.PluginI
 	p.logger.Info("GetPluginInfo called")
 	return &pb.PluginInfo{
 		Name:    "Hello World Plugin",
-		Version: "1.0.0",
+		Version: version,
 	}, nil
 }
 
+// ExecuteCommand runs a command. The logger logs when each one starts and
+// finishes with its request ID, which RunPlugin copies into the response so
+// the host logs the same ID.
 func (p *HelloWorldPlugin) ExecuteCommand(req *pb.CommandRequest) (*pb.CommandResponse, error) {
 	switch req.Command {
 	case "greet":
@@ -91,6 +95,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
 		os.Exit(1)
 	}
+	defer pluginLogger.Close()
 
 	pluginLogger.Info("Hello World plugin starting")
 
@@ -98,45 +103,8 @@ func main() {
 		logger: pluginLogger,
 	}
 
-	for {
-		pluginLogger.Debug("Waiting for message")
-		msgType, msg, err := gsplug.ReadMessage(os.Stdin)
-		if err != nil {
-			if err == io.EOF {
-				pluginLogger.Info("Received EOF, exiting")
-				return
-			}
-			pluginLogger.Error("Error reading message", "error", err)
-			continue
-		}
-		pluginLogger.Debug("Received message", "type", msgType, "content", fmt.Sprintf("%+v", msg))
-
-		var response proto.Message
-		switch msgType {
-		case 1: // GetPluginInfo
-			response, err = plugin.GetPluginInfo(msg.(*pb.PluginInfoRequest))
-		case 2: // ExecuteCommand
-			response, err = plugin.ExecuteCommand(msg.(*pb.CommandRequest))
-		case 3: // GetMenu
-			response, err = plugin.GetMenu(msg.(*pb.MenuRequest))
-		default:
-			err = fmt.Errorf("unknown message type: %d", msgType)
-		}
-
-		if err != nil {
-			pluginLogger.Error("Error handling message", "error", err)
-			continue
-		}
-
-		pluginLogger.Debug("Sending response", "type", msgType, "content", fmt.Sprintf("%+v", response))
-		err = gsplug.WriteMessage(os.Stdout, response)
-		if err != nil {
-			pluginLogger.Error("Error writing response", "error", err)
-		} else {
-			pluginLogger.Debug("Response sent successfully")
-		}
-
-		// Flush stdout to ensure the message is sent immediately
-		os.Stdout.Sync()
-	}
+	// RunPlugin picks the codec from the first byte on stdin, so the plugin
+	// also answers JSON lines typed into a terminal.
+	gsplug.RunPlugin(plugin)
+	pluginLogger.Info("Hello World plugin exiting")
 }
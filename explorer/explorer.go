@@ -0,0 +1,305 @@
+// Package explorer is an interactive terminal UI for a plugin's menu. It
+// presents the menu the way Gitspace does, so plugin authors can preview
+// their plugin without installing it.
+package explorer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	"github.com/ssotops/gitspace-plugin-sdk/host"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+var (
+	titleStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FAFAFA")).
+			Background(lipgloss.Color("#874BFD")).
+			Padding(0, 1)
+
+	spinnerStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#F9E2AF"))
+
+	resultStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#A6E3A1")).
+			Padding(0, 1)
+
+	failureStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#F38BA8")).
+			Padding(0, 1)
+)
+
+// Menu choices that are not an option index.
+const (
+	choiceBack = -1
+	choiceExit = -2
+)
+
+// Explorer walks a plugin's menu. Create one with New and call Run.
+type Explorer struct {
+	client *host.Client
+	out    io.Writer
+	name   string
+	menu   []gsplug.MenuOption
+}
+
+// New loads the plugin's info and menu.
+func New(client *host.Client) (*Explorer, error) {
+	info, err := client.GetPluginInfo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plugin info: %w", err)
+	}
+	menu, err := client.GetMenu()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plugin menu: %w", err)
+	}
+	return &Explorer{
+		client: client,
+		out:    os.Stdout,
+		name:   info.Name,
+		menu:   menu,
+	}, nil
+}
+
+// Run shows the menu until the user exits. Sub-menus are entered and left
+// like in Gitspace; choosing a command asks for its parameters, runs it and
+// prints the result.
+func (e *Explorer) Run() error {
+	fmt.Fprintln(e.out, titleStyle.Render(e.name))
+
+	// path holds the menus entered so far; the last one is shown.
+	path := [][]gsplug.MenuOption{e.menu}
+	titles := []string{e.name}
+	for {
+		menu := path[len(path)-1]
+		choice, err := e.choose(strings.Join(titles, " › "), menu, len(path) > 1)
+		if errors.Is(err, huh.ErrUserAborted) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch choice {
+		case choiceExit:
+			return nil
+		case choiceBack:
+			path, titles = path[:len(path)-1], titles[:len(titles)-1]
+			continue
+		}
+
+		option := menu[choice]
+		if len(option.SubMenu) > 0 {
+			path = append(path, option.SubMenu)
+			titles = append(titles, option.Label)
+			continue
+		}
+		if option.Command == "" {
+			continue
+		}
+		if err := e.execute(option); err != nil {
+			return err
+		}
+	}
+}
+
+func (e *Explorer) choose(title string, menu []gsplug.MenuOption, nested bool) (int, error) {
+	options := make([]huh.Option[int], 0, len(menu)+1)
+	for i, option := range menu {
+		label := option.Label
+		if len(option.SubMenu) > 0 {
+			label += " ›"
+		}
+		options = append(options, huh.NewOption(label, i))
+	}
+	if nested {
+		options = append(options, huh.NewOption("Back", choiceBack))
+	} else {
+		options = append(options, huh.NewOption("Exit", choiceExit))
+	}
+
+	var choice int
+	err := huh.NewForm(huh.NewGroup(
+		huh.NewSelect[int]().
+			Title(title).
+			Options(options...).
+			Value(&choice),
+	)).WithTheme(huh.ThemeCharm()).Run()
+	return choice, err
+}
+
+// execute asks for the option's parameters and runs its command. Aborting
+// the form returns to the menu.
+func (e *Explorer) execute(option gsplug.MenuOption) error {
+	params, err := askParameters(option)
+	if errors.Is(err, huh.ErrUserAborted) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	response, output, err := e.run(option.Command, params)
+	if err != nil {
+		fmt.Fprintln(e.out, failureStyle.Render(err.Error()))
+		return nil
+	}
+
+	body := strings.TrimRight(output+response.Result, "\n")
+	if !response.Success {
+		fmt.Fprintln(e.out, failureStyle.Render(strings.TrimSpace(body+"\n"+response.ErrorMessage)))
+		return nil
+	}
+	if body == "" {
+		body = "Done"
+	}
+	fmt.Fprintln(e.out, resultStyle.Render(body))
+	return nil
+}
+
+// askParameters builds a form from the option's parameters: a confirm for
+// bool parameters and a validated input for everything else.
+func askParameters(option gsplug.MenuOption) (map[string]string, error) {
+	params := make(map[string]string, len(option.Parameters))
+	if len(option.Parameters) == 0 {
+		return params, nil
+	}
+
+	values := make([]string, len(option.Parameters))
+	bools := make([]bool, len(option.Parameters))
+	fields := make([]huh.Field, 0, len(option.Parameters))
+	for i, param := range option.Parameters {
+		title := param.Name
+		if param.Required {
+			title += " *"
+		}
+
+		if param.Type == gsplug.ParameterBool {
+			bools[i] = param.Default == "true"
+			fields = append(fields, huh.NewConfirm().
+				Title(title).
+				Description(param.Description).
+				Value(&bools[i]))
+			continue
+		}
+
+		values[i] = param.Default
+		fields = append(fields, huh.NewInput().
+			Title(title).
+			Description(param.Description).
+			Placeholder(string(typeOf(param))).
+			Value(&values[i]).
+			Validate(validator(param)))
+	}
+
+	err := huh.NewForm(huh.NewGroup(fields...).Title(option.Label)).
+		WithTheme(huh.ThemeCharm()).
+		Run()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, param := range option.Parameters {
+		if param.Type == gsplug.ParameterBool {
+			params[param.Name] = fmt.Sprint(bools[i])
+		} else if values[i] != "" {
+			params[param.Name] = values[i]
+		}
+	}
+	return params, nil
+}
+
+func typeOf(param gsplug.ParameterInfo) gsplug.ParameterType {
+	if param.Type == "" {
+		return gsplug.ParameterString
+	}
+	return param.Type
+}
+
+func validator(param gsplug.ParameterInfo) func(string) error {
+	return func(value string) error {
+		if value == "" {
+			if param.Required {
+				return fmt.Errorf("%s is required", param.Name)
+			}
+			return nil
+		}
+		return param.Validate(value)
+	}
+}
+
+// run executes the command behind a spinner and returns its response and
+// any data the plugin streamed.
+func (e *Explorer) run(command string, params map[string]string) (*pb.CommandResponse, string, error) {
+	model := runModel{
+		command: command,
+		output:  new(bytes.Buffer),
+		spinner: spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(spinnerStyle)),
+		execute: func(w io.Writer) (*pb.CommandResponse, error) {
+			return e.client.ExecuteCommandStream(command, params, w)
+		},
+	}
+
+	final, err := tea.NewProgram(model, tea.WithOutput(e.out)).Run()
+	if err != nil {
+		return nil, "", err
+	}
+	done := final.(runModel)
+	return done.response, done.output.String(), done.err
+}
+
+// runModel shows a spinner while a command runs.
+type runModel struct {
+	command  string
+	spinner  spinner.Model
+	execute  func(io.Writer) (*pb.CommandResponse, error)
+	output   *bytes.Buffer
+	response *pb.CommandResponse
+	err      error
+	done     bool
+}
+
+type commandDoneMsg struct {
+	response *pb.CommandResponse
+	err      error
+}
+
+func (m runModel) Init() tea.Cmd {
+	start := func() tea.Msg {
+		response, err := m.execute(m.output)
+		return commandDoneMsg{response: response, err: err}
+	}
+	return tea.Batch(m.spinner.Tick, start)
+}
+
+func (m runModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case commandDoneMsg:
+		m.response, m.err, m.done = msg.response, msg.err, true
+		return m, tea.Quit
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m runModel) View() string {
+	if m.done {
+		return ""
+	}
+	return m.spinner.View() + " Running " + m.command + "..."
+}
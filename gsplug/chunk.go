@@ -0,0 +1,132 @@
+package gsplug
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+const (
+	// ChunkSize is the largest payload sent in a single chunk.
+	ChunkSize = 64 << 10
+	// ChunkWindow is how many chunks may be unacknowledged before a
+	// ChunkWriter waits for the host, which bounds the data buffered in the
+	// pipe when the host reads slowly.
+	ChunkWindow = 4
+)
+
+// ChecksumChunks returns the checksum sent with the final chunk: the hex
+// SHA-256 of the concatenated chunk data.
+func ChecksumChunks(h hash.Hash) string {
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ChunkWriter streams a large command result to the host as chunks tied to
+// the request's ID. Write it from the command handler, Close it before
+// returning the CommandResponse, and leave Result empty. Like CallHost it
+// must be used from the goroutine running RunPlugin.
+type ChunkWriter struct {
+	requestID string
+	buf       []byte
+	sequence  uint64
+	unacked   int
+	hash      hash.Hash
+	closed    bool
+}
+
+func NewChunkWriter(requestID string) *ChunkWriter {
+	return &ChunkWriter{
+		requestID: requestID,
+		buf:       make([]byte, 0, ChunkSize),
+		hash:      sha256.New(),
+	}
+}
+
+func (w *ChunkWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("write to closed chunk writer")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		n := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+
+		if len(w.buf) == cap(w.buf) {
+			if err := w.send(false); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Close sends the remaining data with the final checksum and waits until the
+// host acknowledged every chunk.
+func (w *ChunkWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if err := w.send(true); err != nil {
+		return err
+	}
+	for w.unacked > 0 {
+		if err := w.waitAck(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *ChunkWriter) send(final bool) error {
+	for w.unacked >= ChunkWindow {
+		if err := w.waitAck(); err != nil {
+			return err
+		}
+	}
+
+	w.hash.Write(w.buf)
+	chunk := &pb.Chunk{
+		RequestId: w.requestID,
+		Sequence:  w.sequence,
+		Data:      w.buf,
+		Final:     final,
+	}
+	if final {
+		chunk.Checksum = ChecksumChunks(w.hash)
+	}
+
+	if err := WriteMessage(os.Stdout, chunk); err != nil {
+		return fmt.Errorf("failed to send chunk: %w", err)
+	}
+	w.sequence++
+	w.unacked++
+	w.buf = w.buf[:0]
+	return nil
+}
+
+func (w *ChunkWriter) waitAck() error {
+	msgType, msg, err := ReadMessage(hostInput)
+	if err != nil {
+		return fmt.Errorf("failed to read chunk ack: %w", err)
+	}
+	if msgType != MessageTypeChunk {
+		return fmt.Errorf("unexpected message type %d while waiting for chunk ack", msgType)
+	}
+
+	ack := msg.(*pb.ChunkAck)
+	if ack.RequestId != w.requestID {
+		return fmt.Errorf("chunk ack for request %q while streaming %q", ack.RequestId, w.requestID)
+	}
+	w.unacked--
+	return nil
+}
@@ -0,0 +1,164 @@
+package gsplug_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// streamPlugin streams size bytes for every command.
+type streamPlugin struct {
+	reposPlugin
+	size int
+}
+
+func (p streamPlugin) ExecuteCommand(req *pb.CommandRequest) (*pb.CommandResponse, error) {
+	w := gsplug.NewChunkWriter(req.RequestId)
+	if _, err := w.Write(streamData(p.size)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return &pb.CommandResponse{Success: true}, nil
+}
+
+func streamData(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+// livePlugin runs RunPlugin in the background with the protobuf framing and
+// lets the test answer its messages one at a time.
+type livePlugin struct {
+	t     *testing.T
+	stdin *os.File
+	msgs  chan proto.Message
+	done  chan struct{}
+}
+
+func startLivePlugin(t *testing.T, handler gsplug.PluginHandler) *livePlugin {
+	t.Helper()
+
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin, stdout, defaultCodec := os.Stdin, os.Stdout, gsplug.DefaultCodec
+	os.Stdin, os.Stdout, gsplug.DefaultCodec = stdinR, stdoutW, gsplug.Framer{}
+
+	p := &livePlugin{t: t, stdin: stdinW, msgs: make(chan proto.Message, 100), done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		gsplug.RunPlugin(handler)
+		stdoutW.Close()
+	}()
+	go func() {
+		defer close(p.msgs)
+		for {
+			_, msg, err := gsplug.Framer{}.ReadPluginMessage(stdoutR)
+			if err != nil {
+				return
+			}
+			p.msgs <- msg
+		}
+	}()
+
+	t.Cleanup(func() {
+		stdinW.Close()
+		<-p.done
+		os.Stdin, os.Stdout, gsplug.DefaultCodec = stdin, stdout, defaultCodec
+		stdinR.Close()
+		stdoutR.Close()
+	})
+	return p
+}
+
+func (p *livePlugin) send(msg proto.Message) {
+	p.t.Helper()
+	if err := (gsplug.Framer{}).WriteMessage(p.stdin, msg); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+// next returns the plugin's next message, or nil if it sends none within
+// wait.
+func (p *livePlugin) next(wait time.Duration) proto.Message {
+	select {
+	case msg := <-p.msgs:
+		return msg
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+func TestChunkWriterWaitsForAcks(t *testing.T) {
+	const chunks = gsplug.ChunkWindow + 3
+	p := startLivePlugin(t, streamPlugin{size: chunks * gsplug.ChunkSize})
+	p.send(&pb.CommandRequest{Command: "stream", RequestId: "req-1"})
+
+	var data []byte
+	var received []*pb.Chunk
+	receive := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			chunk, ok := p.next(5 * time.Second).(*pb.Chunk)
+			if !ok {
+				t.Fatalf("chunk %d was not sent", len(received))
+			}
+			if chunk.RequestId != "req-1" || chunk.Sequence != uint64(len(received)) {
+				t.Fatalf("got chunk %d of %q after %d chunks", chunk.Sequence, chunk.RequestId, len(received))
+			}
+			received = append(received, chunk)
+			data = append(data, chunk.Data...)
+		}
+	}
+	ack := func(chunk *pb.Chunk) {
+		p.send(&pb.ChunkAck{RequestId: chunk.RequestId, Sequence: chunk.Sequence})
+	}
+
+	receive(gsplug.ChunkWindow)
+	if msg := p.next(100 * time.Millisecond); msg != nil {
+		t.Fatalf("plugin sent %v with %d chunks unacknowledged", msg, gsplug.ChunkWindow)
+	}
+	// Each ack lets one more chunk through.
+	for i := 0; len(received) <= chunks; i++ {
+		ack(received[i])
+		receive(1)
+	}
+	if last := received[len(received)-1]; !last.Final || len(received) != chunks+1 {
+		t.Fatalf("chunk %d final %v, want the final chunk after %d full ones", last.Sequence, last.Final, chunks)
+	}
+	if msg := p.next(100 * time.Millisecond); msg != nil {
+		t.Fatalf("plugin answered %v before every chunk was acknowledged", msg)
+	}
+	for _, chunk := range received[len(received)-gsplug.ChunkWindow:] {
+		ack(chunk)
+	}
+
+	response, ok := p.next(5 * time.Second).(*pb.CommandResponse)
+	if !ok || !response.Success {
+		t.Fatalf("got response %v", response)
+	}
+	if !bytes.Equal(data, streamData(chunks*gsplug.ChunkSize)) {
+		t.Fatal("streamed data differs from what was written")
+	}
+	h := sha256.New()
+	h.Write(data)
+	if got := received[len(received)-1].Checksum; got != gsplug.ChecksumChunks(h) {
+		t.Fatalf("final checksum %s does not match the data", got)
+	}
+}
@@ -0,0 +1,222 @@
+package gsplug
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/charmbracelet/log"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec encodes protocol messages on the wire. Framer is the protobuf codec;
+// JSONCodec is a line-based one for debugging and scripting languages.
+type Codec interface {
+	// ReadMessage reads a message sent by the host.
+	ReadMessage(r io.Reader) (uint32, proto.Message, error)
+	// ReadPluginMessage reads a message sent by a plugin.
+	ReadPluginMessage(r io.Reader) (uint32, proto.Message, error)
+	// WriteMessage writes a message in either direction.
+	WriteMessage(w io.Writer, msg proto.Message) error
+}
+
+// CodecName selects a Codec.
+type CodecName string
+
+const (
+	CodecProtobuf CodecName = "protobuf"
+	CodecJSON     CodecName = "json"
+)
+
+// CodecEnv is set by hosts that want a plugin to use a codec other than
+// CodecProtobuf. Plugins run from a terminal can set it by hand.
+const CodecEnv = "GITSPACE_PLUGIN_CODEC"
+
+// DefaultCodec is used by ReadMessage, ReadPluginMessage and WriteMessage.
+// It is DefaultFramer unless CodecEnv selects JSON. RunPlugin also switches
+// to JSON when the first byte it reads is '{'.
+var DefaultCodec Codec = codecFromEnv()
+
+func codecFromEnv() Codec {
+	if CodecName(os.Getenv(CodecEnv)) == CodecJSON {
+		return JSONCodec{}
+	}
+	return DefaultFramer
+}
+
+// JSONCodec writes each message as one line of protojson with an added
+// "type" field naming the message type, for example
+//
+//	{"type":"command","command":"greet","parameters":{"name":"World"}}
+//
+// Blank lines are ignored. The zero value limits lines to
+// DefaultMaxFrameSize.
+type JSONCodec struct {
+	MaxLineSize uint32
+}
+
+var messageTypeNames = map[uint8]string{
+	MessageTypePluginInfo:    "plugin_info",
+	MessageTypeCommand:       "command",
+	MessageTypeMenu:          "menu",
+	MessageTypeSubscriptions: "subscriptions",
+	MessageTypeEvent:         "event",
+	MessageTypeConfig:        "config",
+	MessageTypeUpdateConfig:  "update_config",
+	MessageTypeSecrets:       "secrets",
+	MessageTypeHostCall:      "host_call",
+	MessageTypeChunk:         "chunk",
+	MessageTypeLog:           "log",
+}
+
+func messageTypeByName(name string) (uint32, bool) {
+	for msgType, typeName := range messageTypeNames {
+		if typeName == name {
+			return uint32(msgType), true
+		}
+	}
+	return 0, false
+}
+
+func (c JSONCodec) maxLineSize() uint32 {
+	if c.MaxLineSize == 0 {
+		return DefaultMaxFrameSize
+	}
+	return c.MaxLineSize
+}
+
+func (c JSONCodec) ReadMessage(r io.Reader) (uint32, proto.Message, error) {
+	return c.readMessage(r, newHostMessage)
+}
+
+func (c JSONCodec) ReadPluginMessage(r io.Reader) (uint32, proto.Message, error) {
+	return c.readMessage(r, newPluginMessage)
+}
+
+func (c JSONCodec) readMessage(r io.Reader, newMessage func(uint32) (proto.Message, error)) (uint32, proto.Message, error) {
+	line, err := c.readLine(r)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return 0, nil, fmt.Errorf("%w: failed to parse message: %w", ErrCorruptFrame, err)
+	}
+
+	var typeName string
+	if err := json.Unmarshal(fields["type"], &typeName); err != nil {
+		return 0, nil, fmt.Errorf("%w: message has no \"type\" string", ErrCorruptFrame)
+	}
+	msgType, ok := messageTypeByName(typeName)
+	if !ok {
+		return 0, nil, fmt.Errorf("%w: unknown message type %q", ErrCorruptFrame, typeName)
+	}
+	delete(fields, "type")
+
+	msg, err := newMessage(msgType)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %w", ErrCorruptFrame, err)
+	}
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %w", ErrCorruptFrame, err)
+	}
+	if err := protojson.Unmarshal(body, msg); err != nil {
+		return 0, nil, fmt.Errorf("%w: failed to unmarshal message: %w", ErrCorruptFrame, err)
+	}
+	log.Debug("Read JSON message", "type", typeName, "message", RedactMessage(msg))
+
+	return msgType, msg, nil
+}
+
+// readLine reads up to the next newline one byte at a time, so it never
+// consumes input belonging to the next message. Overlong lines are read to
+// their end and dropped.
+func (c JSONCodec) readLine(r io.Reader) ([]byte, error) {
+	max := c.maxLineSize()
+	for {
+		var line []byte
+		tooLarge := false
+		var b [1]byte
+		for {
+			if _, err := io.ReadFull(r, b[:]); err != nil {
+				if err == io.ErrUnexpectedEOF || (err == io.EOF && len(line) > 0) {
+					return nil, fmt.Errorf("failed to read message line: %w", io.ErrUnexpectedEOF)
+				}
+				return nil, err
+			}
+			if b[0] == '\n' {
+				break
+			}
+			if uint32(len(line)) >= max {
+				tooLarge = true
+				continue
+			}
+			line = append(line, b[0])
+		}
+
+		if tooLarge {
+			return nil, fmt.Errorf("%w: line exceeds limit of %d bytes", ErrFrameTooLarge, max)
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return line, nil
+		}
+	}
+}
+
+func (c JSONCodec) WriteMessage(w io.Writer, msg proto.Message) error {
+	msgType, err := messageType(msg)
+	if err != nil {
+		return err
+	}
+
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	// Re-encode through a map to add the type and get stable, compact
+	// output; protojson deliberately varies its whitespace.
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	typeName, _ := json.Marshal(messageTypeNames[msgType])
+	fields["type"] = typeName
+
+	line, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	log.Debug("Writing JSON message", "type", messageTypeNames[msgType], "message", RedactMessage(msg))
+
+	if _, err := w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
+
+// sniffCodec peeks at the first byte of r and switches DefaultCodec to JSON
+// when it opens a JSON object, so a plugin can be driven from a terminal
+// without setting CodecEnv. The returned reader still yields that byte and
+// otherwise reads r directly.
+func sniffCodec(r io.Reader) (io.Reader, error) {
+	var first [1]byte
+	if _, err := io.ReadFull(r, first[:]); err != nil {
+		return nil, err
+	}
+	if first[0] == '{' {
+		// WriteMessage reads DefaultCodec under writeMu, also from the
+		// goroutines forwarding log records.
+		writeMu.Lock()
+		if _, ok := DefaultCodec.(JSONCodec); !ok {
+			log.Debug("Switching to JSON codec")
+			DefaultCodec = JSONCodec{}
+		}
+		writeMu.Unlock()
+	}
+	return &FrameReader{r: r, pending: first[:]}, nil
+}
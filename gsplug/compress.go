@@ -0,0 +1,91 @@
+package gsplug
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Compression selects how a Framer compresses the frames it writes. Readers
+// decode compressed frames regardless of their own setting.
+type Compression string
+
+const (
+	CompressionNone Compression = ""
+	CompressionGzip Compression = "gzip"
+)
+
+// CompressionEnv is set by hosts that decode compressed frames and want the
+// plugin to compress large ones.
+const CompressionEnv = "GITSPACE_PLUGIN_COMPRESSION"
+
+// DefaultCompressionThreshold is the smallest payload a Framer compresses
+// when its CompressionThreshold is zero. Smaller payloads rarely shrink
+// enough to pay for the gzip header.
+const DefaultCompressionThreshold = 1 << 10
+
+const (
+	// flagGzip marks a gzip-compressed payload in a checked frame's flags.
+	flagGzip = 0x01
+	// legacyTypeGzip is set in a legacy frame's type byte for a
+	// gzip-compressed payload. Message types never use the high bit.
+	legacyTypeGzip = 0x80
+)
+
+// CompressionFromEnv returns the compression requested by the host through
+// CompressionEnv.
+func CompressionFromEnv() Compression {
+	if Compression(os.Getenv(CompressionEnv)) == CompressionGzip {
+		return CompressionGzip
+	}
+	return CompressionNone
+}
+
+func (f Framer) compressionThreshold() int {
+	if f.CompressionThreshold == 0 {
+		return DefaultCompressionThreshold
+	}
+	return f.CompressionThreshold
+}
+
+// compress returns data gzip-compressed if the framer compresses and that
+// makes it smaller.
+func (f Framer) compress(data []byte) ([]byte, bool) {
+	if f.Compression != CompressionGzip || len(data) < f.compressionThreshold() {
+		return data, false
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return data, false
+	}
+	if err := zw.Close(); err != nil {
+		return data, false
+	}
+	if buf.Len() >= len(data) {
+		return data, false
+	}
+	return buf.Bytes(), true
+}
+
+// decompress inflates a gzip payload, refusing to produce more than the
+// framer's size limit.
+func (f Framer) decompress(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decompress frame: %w", ErrCorruptFrame, err)
+	}
+
+	max := f.maxFrameSize()
+	out, err := io.ReadAll(io.LimitReader(zr, int64(max)+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decompress frame: %w", ErrCorruptFrame, err)
+	}
+	if uint32(len(out)) > max {
+		return nil, fmt.Errorf("%w: decompressed payload exceeds limit of %d", ErrFrameTooLarge, max)
+	}
+	return out, nil
+}
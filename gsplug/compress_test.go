@@ -0,0 +1,115 @@
+package gsplug_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// compressedFlag reports whether an encoded frame is marked as gzip.
+func compressedFlag(framing gsplug.Framing, frame []byte) bool {
+	if framing == gsplug.FramingChecked {
+		return frame[2]&0x01 != 0
+	}
+	return frame[0]&0x80 != 0
+}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	random := make([]byte, 4<<10)
+	rand.Read(random)
+
+	tests := []struct {
+		name       string
+		data       []byte
+		compressed bool
+	}{
+		{"below threshold", bytes.Repeat([]byte("x"), 100), false},
+		{"compressible", bytes.Repeat([]byte("gitspace "), 1000), true},
+		{"incompressible", random, false},
+	}
+	for _, framing := range []gsplug.Framing{gsplug.FramingLegacy, gsplug.FramingChecked} {
+		for _, tt := range tests {
+			t.Run(string(framing)+"/"+tt.name, func(t *testing.T) {
+				writer := gsplug.Framer{Framing: framing, Compression: gsplug.CompressionGzip}
+				msg := &pb.Chunk{RequestId: "r", Data: tt.data}
+				frame := encode(t, writer, msg)
+				if got := compressedFlag(framing, frame); got != tt.compressed {
+					t.Fatalf("compressed %v, want %v", got, tt.compressed)
+				}
+				plain := encode(t, gsplug.Framer{Framing: framing}, msg)
+				if tt.compressed && len(frame) >= len(plain) {
+					t.Fatalf("compressed frame is %d bytes, plain frame %d", len(frame), len(plain))
+				}
+
+				// Readers decode compressed frames whatever they write.
+				reader := gsplug.Framer{Framing: framing}
+				_, decoded, err := reader.ReadPluginMessage(gsplug.NewFrameReader(bytes.NewReader(frame)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !proto.Equal(decoded, msg) {
+					t.Fatal("round trip changed the message")
+				}
+			})
+		}
+	}
+}
+
+func TestCompressionThreshold(t *testing.T) {
+	msg := &pb.CommandResponse{Result: strings.Repeat("a", 200)}
+	framer := gsplug.Framer{Compression: gsplug.CompressionGzip, CompressionThreshold: 100}
+	if !compressedFlag(gsplug.FramingLegacy, encode(t, framer, msg)) {
+		t.Fatal("payload over a custom threshold was not compressed")
+	}
+	if compressedFlag(gsplug.FramingLegacy, encode(t, gsplug.Framer{CompressionThreshold: 100}, msg)) {
+		t.Fatal("a framer without compression compressed")
+	}
+}
+
+func TestDecompressionLimit(t *testing.T) {
+	payload, err := proto.Marshal(&pb.CommandResponse{Result: strings.Repeat("z", 1<<20)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(payload)
+	zw.Close()
+
+	// A small legacy frame that inflates past the reader's limit.
+	var frame bytes.Buffer
+	frame.WriteByte(gsplug.MessageTypeCommand | 0x80)
+	binary.Write(&frame, binary.LittleEndian, uint32(gz.Len()))
+	frame.Write(gz.Bytes())
+
+	_, _, err = gsplug.Framer{MaxFrameSize: 64 << 10}.ReadPluginMessage(&frame)
+	if !errors.Is(err, gsplug.ErrFrameTooLarge) {
+		t.Fatalf("got error %v, want ErrFrameTooLarge", err)
+	}
+
+	garbage := []byte{gsplug.MessageTypeCommand | 0x80, 3, 0, 0, 0, 'b', 'a', 'd'}
+	if _, _, err := (gsplug.Framer{}).ReadPluginMessage(bytes.NewReader(garbage)); !errors.Is(err, gsplug.ErrCorruptFrame) {
+		t.Fatalf("got error %v for an invalid gzip payload, want ErrCorruptFrame", err)
+	}
+}
+
+func TestCompressionFromEnv(t *testing.T) {
+	for value, want := range map[string]gsplug.Compression{
+		"gzip": gsplug.CompressionGzip,
+		"":     gsplug.CompressionNone,
+		"zstd": gsplug.CompressionNone,
+	} {
+		t.Setenv(gsplug.CompressionEnv, value)
+		if got := gsplug.CompressionFromEnv(); got != want {
+			t.Errorf("%s=%q: got %q, want %q", gsplug.CompressionEnv, value, got, want)
+		}
+	}
+}
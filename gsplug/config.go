@@ -0,0 +1,310 @@
+package gsplug
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+// ConfigProvider is implemented by plugins with host-managed settings. The
+// SDK answers the host's config requests on the plugin's behalf.
+type ConfigProvider interface {
+	PluginConfig() *Config
+}
+
+// ConfigHandler is implemented by plugins that answer the host's config
+// requests themselves, such as services adapted with FromService. A
+// ConfigProvider takes precedence when a plugin implements both.
+type ConfigHandler interface {
+	GetConfig(*pb.ConfigRequest) (*pb.ConfigResponse, error)
+	UpdateConfig(*pb.UpdateConfigRequest) (*pb.UpdateConfigResponse, error)
+}
+
+// Config holds a plugin's settings. Values are resolved from the schema
+// defaults, then ~/.ssot/gitspace/config/<plugin>.toml, then environment
+// variables named GITSPACE_<PLUGIN>_<SETTING>.
+type Config struct {
+	mu         sync.RWMutex
+	schema     []ParameterInfo
+	path       string
+	envPrefix  string
+	fileValues map[string]string
+	values     map[string]string
+}
+
+// LoadConfig loads and validates the settings of pluginName against schema.
+func LoadConfig(pluginName string, schema []ParameterInfo) (*Config, error) {
+	path, err := GetPluginConfigPath(pluginName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plugin config path: %w", err)
+	}
+	return LoadConfigFile(path, ConfigEnvPrefix(pluginName), schema)
+}
+
+// LoadConfigFile is like LoadConfig with an explicit file path and
+// environment variable prefix. A missing file is not an error.
+func LoadConfigFile(path, envPrefix string, schema []ParameterInfo) (*Config, error) {
+	c := &Config{
+		schema:     schema,
+		path:       path,
+		envPrefix:  envPrefix,
+		fileValues: make(map[string]string),
+	}
+
+	var raw map[string]interface{}
+	_, err := toml.DecodeFile(path, &raw)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to decode config %s: %w", path, err)
+	}
+	for name, value := range raw {
+		if c.field(name) == nil {
+			continue
+		}
+		c.fileValues[name] = fmt.Sprint(value)
+	}
+
+	c.resolve()
+	if err := c.validate(c.values); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ConfigEnvPrefix returns the environment variable prefix for pluginName,
+// e.g. "GITSPACE_HELLO_WORLD_".
+func ConfigEnvPrefix(pluginName string) string {
+	return "GITSPACE_" + envName(pluginName) + "_"
+}
+
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+func (c *Config) field(name string) *ParameterInfo {
+	for i := range c.schema {
+		if c.schema[i].Name == name {
+			return &c.schema[i]
+		}
+	}
+	return nil
+}
+
+// resolve recomputes the effective values. Callers must hold c.mu or own c
+// exclusively.
+func (c *Config) resolve() {
+	c.values = make(map[string]string, len(c.schema))
+	for _, field := range c.schema {
+		value := field.Default
+		if fileValue, ok := c.fileValues[field.Name]; ok {
+			value = fileValue
+		}
+		if envValue, ok := os.LookupEnv(c.envPrefix + envName(field.Name)); ok {
+			value = envValue
+		}
+		c.values[field.Name] = value
+	}
+}
+
+func (c *Config) validate(values map[string]string) error {
+	var errs []error
+	for _, field := range c.schema {
+		if err := field.Validate(values[field.Name]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Schema returns the settings the plugin declared.
+func (c *Config) Schema() []ParameterInfo {
+	return c.schema
+}
+
+// Values returns a copy of the effective settings.
+func (c *Config) Values() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	values := make(map[string]string, len(c.values))
+	for name, value := range c.values {
+		values[name] = value
+	}
+	return values
+}
+
+func (c *Config) String(name string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.values[name]
+}
+
+// Int returns the named setting as an int, or 0 if it is empty or not an
+// int setting.
+func (c *Config) Int(name string) int {
+	value, _ := strconv.Atoi(c.String(name))
+	return value
+}
+
+func (c *Config) Float(name string) float64 {
+	value, _ := strconv.ParseFloat(c.String(name), 64)
+	return value
+}
+
+func (c *Config) Bool(name string) bool {
+	value, _ := strconv.ParseBool(c.String(name))
+	return value
+}
+
+// Set validates values, merges them into the config file and saves it.
+// Settings not mentioned in values keep their current file value; an empty
+// value removes the setting from the file, so it falls back to its default.
+func (c *Config) Set(values map[string]string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fileValues := make(map[string]string, len(c.fileValues))
+	for name, value := range c.fileValues {
+		fileValues[name] = value
+	}
+	var errs []error
+	for name, value := range values {
+		field := c.field(name)
+		if field == nil {
+			return fmt.Errorf("unknown setting %q", name)
+		}
+		// Validate the value itself, since an environment override would
+		// hide it from the check on the effective values below.
+		if err := field.Validate(value); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if value == "" {
+			delete(fileValues, name)
+		} else {
+			fileValues[name] = value
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	previous := c.fileValues
+	c.fileValues = fileValues
+	c.resolve()
+	err := c.validate(c.values)
+	if err == nil {
+		err = c.save()
+	}
+	if err != nil {
+		c.fileValues = previous
+		c.resolve()
+		return err
+	}
+	return nil
+}
+
+// save writes the file values with their declared types to a temporary file
+// and renames it over the config file, so a crash or a concurrent reader
+// never sees a partial file. Callers must hold c.mu.
+func (c *Config) save() error {
+	typed := make(map[string]interface{}, len(c.fileValues))
+	for name, value := range c.fileValues {
+		var err error
+		switch c.field(name).Type {
+		case ParameterInt:
+			typed[name], err = strconv.ParseInt(value, 10, 64)
+		case ParameterFloat:
+			typed[name], err = strconv.ParseFloat(value, 64)
+		case ParameterBool:
+			typed[name], err = strconv.ParseBool(value)
+		default:
+			typed[name] = value
+		}
+		if err != nil {
+			return fmt.Errorf("failed to encode setting %q: %w", name, err)
+		}
+	}
+
+	dir := filepath.Dir(c.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(c.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary config file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := toml.NewEncoder(tmp).Encode(typed); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode config file: %w", err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set config file permissions: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temporary config file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary config file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to replace config file: %w", err)
+	}
+	return nil
+}
+
+func (c *Config) describe() (*pb.ConfigResponse, error) {
+	schemaBytes, err := json.Marshal(c.schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config schema: %w", err)
+	}
+	return &pb.ConfigResponse{
+		SchemaData: schemaBytes,
+		Values:     c.Values(),
+	}, nil
+}
+
+// noSettingsResponse answers a config update for a plugin without settings.
+func noSettingsResponse() *pb.UpdateConfigResponse {
+	return &pb.UpdateConfigResponse{
+		Success:      false,
+		ErrorMessage: "plugin has no configurable settings",
+	}
+}
+
+func (c *Config) update(req *pb.UpdateConfigRequest) *pb.UpdateConfigResponse {
+	if err := c.Set(req.Values); err != nil {
+		return &pb.UpdateConfigResponse{
+			Success:      false,
+			ErrorMessage: err.Error(),
+		}
+	}
+	return &pb.UpdateConfigResponse{Success: true}
+}
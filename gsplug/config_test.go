@@ -0,0 +1,130 @@
+package gsplug_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+)
+
+var configSchema = []gsplug.ParameterInfo{
+	{Name: "api_url", Default: "https://example.com"},
+	{Name: "port", Type: gsplug.ParameterInt, Default: "80"},
+	{Name: "verbose", Type: gsplug.ParameterBool},
+}
+
+func TestConfigResolution(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plugin.toml")
+	if err := os.WriteFile(path, []byte("port = 8080\nverbose = true\nunknown = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITSPACE_TEST_API_URL", "https://env.example.com")
+
+	config, err := gsplug.LoadConfigFile(path, "GITSPACE_TEST_", configSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"api_url": "https://env.example.com", "port": "8080", "verbose": "true"}
+	if got := config.Values(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Values = %v, want %v", got, want)
+	}
+	if config.Int("port") != 8080 || !config.Bool("verbose") {
+		t.Fatalf("typed accessors returned %d, %v", config.Int("port"), config.Bool("verbose"))
+	}
+
+	if err := os.WriteFile(path, []byte("port = \"many\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gsplug.LoadConfigFile(path, "GITSPACE_TEST_", configSchema); err == nil {
+		t.Fatal("an invalid file value was accepted")
+	}
+}
+
+func TestConfigSetSaves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "plugin.toml")
+	config, err := gsplug.LoadConfigFile(path, "GITSPACE_TEST_", configSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := config.Set(map[string]string{"port": "9090", "verbose": "true"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Set(map[string]string{"verbose": ""}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "port = 9090" {
+		t.Fatalf("saved %q", got)
+	}
+	reloaded, err := gsplug.LoadConfigFile(path, "GITSPACE_TEST_", configSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Int("port") != 9090 || reloaded.String("verbose") != "" {
+		t.Fatalf("reloaded %v", reloaded.Values())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("left temporary files behind: %v", entries)
+	}
+}
+
+func TestConfigSetRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		error  string
+	}{
+		{"unknown setting", map[string]string{"colour": "red"}, `unknown setting "colour"`},
+		{"wrong type", map[string]string{"port": "abc"}, "expects type int"},
+		{"overridden by the environment", map[string]string{"api_url": "https://new.example.com", "port": "abc"}, "expects type int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITSPACE_TEST_PORT", "8080")
+			path := filepath.Join(t.TempDir(), "plugin.toml")
+			config, err := gsplug.LoadConfigFile(path, "GITSPACE_TEST_", configSchema)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = config.Set(tt.values)
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Fatalf("got error %v, want %q", err, tt.error)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Fatalf("a rejected update was saved: %v", err)
+			}
+			if got := config.String("api_url"); got != "https://example.com" {
+				t.Fatalf("a rejected update changed api_url to %q", got)
+			}
+		})
+	}
+}
+
+func TestConfigSetRestoresOnSaveFailure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "config")
+	config, err := gsplug.LoadConfigFile(filepath.Join(dir, "plugin.toml"), "GITSPACE_TEST_", configSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The config directory cannot be created over a regular file.
+	if err := os.WriteFile(dir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := config.Set(map[string]string{"port": "9090"}); err == nil {
+		t.Fatal("Set succeeded without saving")
+	}
+	if got := config.String("port"); got != "80" {
+		t.Fatalf("port is %q after a failed save, want the default", got)
+	}
+}
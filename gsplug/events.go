@@ -0,0 +1,129 @@
+package gsplug
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+// EventType identifies a Gitspace event a plugin can subscribe to.
+type EventType string
+
+const (
+	EventRepoCloned      EventType = "repo.cloned"
+	EventRepoSynced      EventType = "repo.synced"
+	EventBranchCreated   EventType = "branch.created"
+	EventWorkspaceOpened EventType = "workspace.opened"
+	EventScheduleTick    EventType = "schedule.tick"
+)
+
+// KnownEventTypes lists every event type the host can deliver.
+var KnownEventTypes = []EventType{
+	EventRepoCloned,
+	EventRepoSynced,
+	EventBranchCreated,
+	EventWorkspaceOpened,
+	EventScheduleTick,
+}
+
+// IsKnownEventType reports whether eventType is one the host can deliver.
+func IsKnownEventType(eventType EventType) bool {
+	for _, known := range KnownEventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// EventSubscriber is implemented by plugins that tell the host at startup
+// which events they want to receive.
+type EventSubscriber interface {
+	GetSubscriptions(*pb.SubscriptionRequest) (*pb.SubscriptionResponse, error)
+}
+
+// EventHandler is implemented by plugins that react to events pushed by the
+// host.
+type EventHandler interface {
+	HandleEvent(*pb.Event) (*pb.EventResponse, error)
+}
+
+// EventFunc handles a single event delivered by the host.
+type EventFunc func(*pb.Event) error
+
+// EventRouter routes host events to the functions registered for their type.
+// Embedding an *EventRouter in a plugin makes it satisfy both EventSubscriber
+// and EventHandler.
+type EventRouter struct {
+	mu       sync.RWMutex
+	handlers map[EventType][]EventFunc
+}
+
+func NewEventRouter() *EventRouter {
+	return &EventRouter{
+		handlers: make(map[EventType][]EventFunc),
+	}
+}
+
+// On registers fn for eventType and subscribes the plugin to it.
+func (r *EventRouter) On(eventType EventType, fn EventFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers[eventType] = append(r.handlers[eventType], fn)
+}
+
+// Subscriptions returns the event types that have at least one handler.
+func (r *EventRouter) Subscriptions() []EventType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]EventType, 0, len(r.handlers))
+	for eventType := range r.handlers {
+		events = append(events, eventType)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i] < events[j] })
+	return events
+}
+
+func (r *EventRouter) GetSubscriptions(req *pb.SubscriptionRequest) (*pb.SubscriptionResponse, error) {
+	subscriptions := r.Subscriptions()
+	events := make([]string, len(subscriptions))
+	for i, eventType := range subscriptions {
+		events[i] = string(eventType)
+	}
+	return &pb.SubscriptionResponse{Events: events}, nil
+}
+
+// HandleEvent runs every handler registered for the event's type. All
+// handlers run even if one fails; their errors are joined in the response.
+func (r *EventRouter) HandleEvent(event *pb.Event) (*pb.EventResponse, error) {
+	r.mu.RLock()
+	handlers := r.handlers[EventType(event.Type)]
+	r.mu.RUnlock()
+
+	if len(handlers) == 0 {
+		return &pb.EventResponse{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("no handler registered for event %q", event.Type),
+		}, nil
+	}
+
+	var errs []string
+	for _, fn := range handlers {
+		if err := fn(event); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return &pb.EventResponse{
+			Success:      false,
+			ErrorMessage: strings.Join(errs, "; "),
+		}, nil
+	}
+	return &pb.EventResponse{Success: true}, nil
+}
@@ -0,0 +1,341 @@
+package gsplug
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+
+	"github.com/charmbracelet/log"
+	"google.golang.org/protobuf/proto"
+)
+
+// Framing selects the wire format of frames.
+type Framing string
+
+const (
+	// FramingLegacy frames are a type byte, a little-endian uint32 length and
+	// the payload. It is what Gitspace speaks unless told otherwise.
+	FramingLegacy Framing = "legacy"
+	// FramingChecked frames start with the "GS" marker and a flags byte and
+	// end with a CRC-32 of everything after the marker, so a reader can
+	// detect corruption and resynchronize on the next marker.
+	FramingChecked Framing = "checked"
+)
+
+// FramingEnv is set by hosts that want a plugin to use a framing other than
+// FramingLegacy.
+const FramingEnv = "GITSPACE_PLUGIN_FRAMING"
+
+// DefaultMaxFrameSize is the largest payload a Framer accepts when its
+// MaxFrameSize is zero.
+const DefaultMaxFrameSize = 16 << 20
+
+var (
+	// ErrFrameTooLarge is returned when a frame's declared length exceeds
+	// the reader's limit. The payload is never allocated.
+	ErrFrameTooLarge = errors.New("frame too large")
+	// ErrCorruptFrame is returned for a frame that failed its checksum or
+	// could not be decoded. The frame is dropped; the stream stays usable.
+	ErrCorruptFrame = errors.New("corrupt frame")
+	// ErrDesynchronized wraps errors after which the reader no longer knows
+	// where the next frame starts. The stream must not be read again.
+	ErrDesynchronized = errors.New("stream desynchronized")
+)
+
+var frameMagic = [2]byte{'G', 'S'}
+
+// Framer is the protobuf Codec. It reads and writes frames in one framing
+// with a size limit. The zero
+// value uses FramingLegacy and DefaultMaxFrameSize and does not compress.
+type Framer struct {
+	Framing      Framing
+	MaxFrameSize uint32
+	// Compression compresses written payloads of at least
+	// CompressionThreshold bytes (DefaultCompressionThreshold when zero).
+	Compression          Compression
+	CompressionThreshold int
+}
+
+// DefaultFramer is the DefaultCodec unless JSON was selected. Its framing and compression come from FramingEnv and CompressionEnv.
+var DefaultFramer = Framer{
+	Framing:     FramingFromEnv(),
+	Compression: CompressionFromEnv(),
+}
+
+// FramingFromEnv returns the framing requested by the host through
+// FramingEnv, defaulting to FramingLegacy.
+func FramingFromEnv() Framing {
+	if Framing(os.Getenv(FramingEnv)) == FramingChecked {
+		return FramingChecked
+	}
+	return FramingLegacy
+}
+
+// Recoverable reports whether a read error left the stream usable, so the
+// caller may keep reading. EOF and I/O errors are not recoverable.
+func Recoverable(err error) bool {
+	if errors.Is(err, ErrDesynchronized) {
+		return false
+	}
+	return errors.Is(err, ErrCorruptFrame) || errors.Is(err, ErrFrameTooLarge)
+}
+
+// maxFrameSize leaves room for the checksum that follows a checked frame's
+// payload, so the frame's size always fits in a uint32.
+func (f Framer) maxFrameSize() uint32 {
+	if f.MaxFrameSize == 0 {
+		return DefaultMaxFrameSize
+	}
+	return min(f.MaxFrameSize, math.MaxUint32-4)
+}
+
+// ReadMessage reads a message sent by the host.
+func (f Framer) ReadMessage(r io.Reader) (uint32, proto.Message, error) {
+	return f.readMessage(r, newHostMessage)
+}
+
+// ReadPluginMessage reads a message sent by a plugin.
+func (f Framer) ReadPluginMessage(r io.Reader) (uint32, proto.Message, error) {
+	return f.readMessage(r, newPluginMessage)
+}
+
+func (f Framer) readMessage(r io.Reader, newMessage func(uint32) (proto.Message, error)) (uint32, proto.Message, error) {
+	msgType, data, err := f.readFrame(r)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	msg, err := newMessage(uint32(msgType))
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %w", ErrCorruptFrame, err)
+	}
+
+	err = proto.Unmarshal(data, msg)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: failed to unmarshal message: %w", ErrCorruptFrame, err)
+	}
+	log.Debug("Read message data", "dataLength", len(data), "rawData", dumpFrame(msg, data))
+
+	return uint32(msgType), msg, nil
+}
+
+func (f Framer) readFrame(r io.Reader) (uint8, []byte, error) {
+	var msgType uint8
+	var data []byte
+	var compressed bool
+	var err error
+	if f.Framing == FramingChecked {
+		msgType, data, compressed, err = f.readCheckedFrame(r)
+	} else {
+		msgType, data, compressed, err = f.readLegacyFrame(r)
+	}
+	if err != nil || !compressed {
+		return msgType, data, err
+	}
+
+	data, err = f.decompress(data)
+	if err != nil {
+		return 0, nil, err
+	}
+	log.Debug("Decompressed frame", "type", msgType, "length", len(data))
+	return msgType, data, nil
+}
+
+func (f Framer) readLegacyFrame(r io.Reader) (uint8, []byte, bool, error) {
+	var msgType [1]byte
+	_, err := io.ReadFull(r, msgType[:])
+	if err != nil {
+		return 0, nil, false, fmt.Errorf("failed to read message type: %w", err)
+	}
+	log.Debug("Read message type", "type", msgType[0])
+
+	var msgLen uint32
+	err = binary.Read(r, binary.LittleEndian, &msgLen)
+	if err != nil {
+		return 0, nil, false, fmt.Errorf("failed to read message length: %w", err)
+	}
+	log.Debug("Read message length", "length", msgLen)
+
+	if max := f.maxFrameSize(); msgLen > max {
+		return 0, nil, false, fmt.Errorf("%w: %w: %d bytes exceeds limit of %d", ErrDesynchronized, ErrFrameTooLarge, msgLen, max)
+	}
+
+	data := make([]byte, msgLen)
+	_, err = io.ReadFull(r, data)
+	if err != nil {
+		return 0, nil, false, fmt.Errorf("failed to read message data: %w", err)
+	}
+
+	compressed := msgType[0]&legacyTypeGzip != 0
+	return msgType[0] &^ legacyTypeGzip, data, compressed, nil
+}
+
+// readCheckedFrame scans for the next frame marker, skipping anything in
+// between, then reads and verifies the frame. When the frame turns out to
+// be corrupt and r came from NewFrameReader, the bytes read after the
+// marker are pushed back so the next read resumes scanning right after it
+// instead of trusting the frame's unverified length.
+func (f Framer) readCheckedFrame(r io.Reader) (uint8, []byte, bool, error) {
+	read := 0
+	var prev byte
+	var b [1]byte
+	for {
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return 0, nil, false, fmt.Errorf("failed to read frame marker: %w", err)
+		}
+		read++
+		if read >= len(frameMagic) && prev == frameMagic[0] && b[0] == frameMagic[1] {
+			break
+		}
+		prev = b[0]
+	}
+	if skipped := read - len(frameMagic); skipped > 0 {
+		log.Warn("Skipped bytes before frame marker", "bytes", skipped)
+	}
+
+	// flags, type, length
+	var header [6]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, false, fmt.Errorf("failed to read frame header: %w", err)
+	}
+	flags, msgType := header[0], header[1]
+	msgLen := binary.LittleEndian.Uint32(header[2:])
+	log.Debug("Read frame header", "type", msgType, "flags", flags, "length", msgLen)
+
+	if flags&^flagGzip != 0 {
+		unread(r, header[:])
+		return 0, nil, false, fmt.Errorf("%w: unsupported frame flags %#x", ErrCorruptFrame, flags)
+	}
+	if max := f.maxFrameSize(); msgLen > max {
+		unread(r, header[:])
+		return 0, nil, false, fmt.Errorf("%w: %d bytes exceeds limit of %d", ErrFrameTooLarge, msgLen, max)
+	}
+
+	data := make([]byte, int64(msgLen)+4)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, false, fmt.Errorf("failed to read frame data: %w", err)
+	}
+	payload, sum := data[:msgLen], binary.LittleEndian.Uint32(data[msgLen:])
+
+	if checksum(header[:], payload) != sum {
+		unread(r, append(header[:], data...))
+		return 0, nil, false, fmt.Errorf("%w: checksum mismatch for message type %d", ErrCorruptFrame, msgType)
+	}
+	return msgType, payload, flags&flagGzip != 0, nil
+}
+
+// FrameReader buffers bytes a reader gave back, so a corrupt checked frame
+// can be rescanned for the marker of the frame that follows it.
+type FrameReader struct {
+	r       io.Reader
+	pending []byte
+}
+
+// NewFrameReader wraps a stream that is read with FramingChecked.
+func NewFrameReader(r io.Reader) *FrameReader {
+	if fr, ok := r.(*FrameReader); ok {
+		return fr
+	}
+	return &FrameReader{r: r}
+}
+
+func (fr *FrameReader) Read(p []byte) (int, error) {
+	if len(fr.pending) > 0 {
+		n := copy(p, fr.pending)
+		fr.pending = fr.pending[n:]
+		return n, nil
+	}
+	return fr.r.Read(p)
+}
+
+// unread gives b back to r so it is read again, when r supports it.
+func unread(r io.Reader, b []byte) {
+	fr, ok := r.(*FrameReader)
+	if !ok {
+		return
+	}
+	fr.pending = append(append([]byte(nil), b...), fr.pending...)
+}
+
+func checksum(header, data []byte) uint32 {
+	sum := crc32.ChecksumIEEE(header)
+	return crc32.Update(sum, crc32.IEEETable, data)
+}
+
+// WriteMessage writes msg in the framer's framing; the frame type is derived
+// from the message.
+func (f Framer) WriteMessage(w io.Writer, msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	log.Debug("Marshaled message", "dataLength", len(data), "rawData", dumpFrame(msg, data))
+
+	msgType, err := messageType(msg)
+	if err != nil {
+		return err
+	}
+
+	data, compressed := f.compress(data)
+	if compressed {
+		log.Debug("Compressed message", "type", msgType, "dataLength", len(data))
+	}
+
+	if f.Framing == FramingChecked {
+		var flags uint8
+		if compressed {
+			flags |= flagGzip
+		}
+		return writeCheckedFrame(w, flags, msgType, data)
+	}
+	if compressed {
+		msgType |= legacyTypeGzip
+	}
+	return writeLegacyFrame(w, msgType, data)
+}
+
+func writeLegacyFrame(w io.Writer, msgType uint8, data []byte) error {
+	log.Debug("Writing message type", "type", msgType)
+	if _, err := w.Write([]byte{msgType}); err != nil {
+		return fmt.Errorf("failed to write message type: %w", err)
+	}
+
+	log.Debug("Writing message length", "length", len(data))
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(data))); err != nil {
+		return fmt.Errorf("failed to write message length: %w", err)
+	}
+
+	log.Debug("Writing message data", "dataLength", len(data))
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write message data: %w", err)
+	}
+
+	return nil
+}
+
+// writeCheckedFrame writes the whole frame with a single Write so frames
+// from concurrent writers never interleave.
+func writeCheckedFrame(w io.Writer, flags, msgType uint8, data []byte) error {
+	var header [6]byte
+	header[0] = flags
+	header[1] = msgType
+	binary.LittleEndian.PutUint32(header[2:], uint32(len(data)))
+
+	var frame bytes.Buffer
+	frame.Grow(len(frameMagic) + len(header) + len(data) + 4)
+	frame.Write(frameMagic[:])
+	frame.Write(header[:])
+	frame.Write(data)
+	binary.Write(&frame, binary.LittleEndian, checksum(header[:], data))
+
+	log.Debug("Writing frame", "type", msgType, "length", len(data))
+	if _, err := w.Write(frame.Bytes()); err != nil {
+		return fmt.Errorf("failed to write frame: %w", err)
+	}
+	return nil
+}
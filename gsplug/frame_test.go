@@ -0,0 +1,227 @@
+package gsplug_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+func encode(t testing.TB, codec gsplug.Codec, msgs ...proto.Message) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, msg := range msgs {
+		if err := codec.WriteMessage(&buf, msg); err != nil {
+			t.Fatalf("failed to encode %T: %v", msg, err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func command(name string) *pb.CommandRequest {
+	return &pb.CommandRequest{Command: name, Parameters: map[string]string{"repo": "sdk"}}
+}
+
+func FuzzReadMessage(f *testing.F) {
+	legacy := gsplug.Framer{}
+	checked := gsplug.Framer{Framing: gsplug.FramingChecked}
+	jsonCodec := gsplug.JSONCodec{}
+	for _, codec := range []gsplug.Codec{legacy, checked, jsonCodec} {
+		f.Add(encode(f, codec, command("greet"), &pb.PluginInfoRequest{}))
+		f.Add(encode(f, codec, &pb.Event{Type: "repo.synced", Attributes: map[string]string{"a": "b"}}))
+	}
+	f.Add([]byte("GS\x00\x02\xff\xff\xff\xffGS"))
+	f.Add([]byte("\x02\xff\xff\xff\x7f"))
+	f.Add([]byte("{\"type\":\"command\"\n\n{}\n"))
+
+	codecs := map[string]gsplug.Codec{
+		"legacy":  gsplug.Framer{MaxFrameSize: 1 << 16},
+		"checked": gsplug.Framer{Framing: gsplug.FramingChecked, MaxFrameSize: 1 << 16},
+		"json":    gsplug.JSONCodec{MaxLineSize: 1 << 16},
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for name, codec := range codecs {
+			r := gsplug.NewFrameReader(bytes.NewReader(data))
+			// Every read consumes at least one byte, so the stream ends
+			// within len(data)+1 reads.
+			for reads := 0; ; reads++ {
+				if reads > len(data) {
+					t.Fatalf("%s: no progress after %d reads", name, reads)
+				}
+				_, msg, err := codec.ReadMessage(r)
+				if err != nil {
+					if !gsplug.Recoverable(err) {
+						break
+					}
+					continue
+				}
+
+				var buf bytes.Buffer
+				if err := codec.WriteMessage(&buf, msg); err != nil {
+					t.Fatalf("%s: failed to re-encode %T: %v", name, msg, err)
+				}
+				_, again, err := codec.ReadMessage(&buf)
+				if err != nil {
+					t.Fatalf("%s: failed to decode re-encoded %T: %v", name, msg, err)
+				}
+				if !proto.Equal(msg, again) {
+					t.Fatalf("%s: round trip changed %v to %v", name, msg, again)
+				}
+			}
+		}
+	})
+}
+
+// corrupt returns a copy of frame with fn applied.
+func corrupt(frame []byte, fn func([]byte)) []byte {
+	frame = bytes.Clone(frame)
+	fn(frame)
+	return frame
+}
+
+func TestCheckedFramingResync(t *testing.T) {
+	framer := gsplug.Framer{Framing: gsplug.FramingChecked, MaxFrameSize: 1 << 10}
+	first := encode(t, framer, command("first"))
+	second := encode(t, framer, command("second"))
+	// Offsets in a checked frame: marker, flags, type, length, payload.
+	const flagsAt, lengthAt, payloadAt = 2, 4, 8
+
+	tests := []struct {
+		name  string
+		input []byte
+		// errs lists the errors expected before "second" is read.
+		errs []error
+	}{
+		{
+			name:  "clean",
+			input: concat(second),
+		},
+		{
+			name:  "garbage before marker",
+			input: concat([]byte("noise G S"), second),
+		},
+		{
+			name:  "checksum mismatch",
+			input: concat(corrupt(first, func(b []byte) { b[payloadAt] ^= 0xff }), second),
+			errs:  []error{gsplug.ErrCorruptFrame},
+		},
+		{
+			name: "length covering the next frame",
+			input: concat(corrupt(first, func(b []byte) {
+				length := binary.LittleEndian.Uint32(b[lengthAt:])
+				binary.LittleEndian.PutUint32(b[lengthAt:], length+uint32(len(second)))
+			}), second),
+			errs: []error{gsplug.ErrCorruptFrame},
+		},
+		{
+			name:  "unknown flags",
+			input: concat(corrupt(first, func(b []byte) { b[flagsAt] = 0x80 }), second),
+			errs:  []error{gsplug.ErrCorruptFrame},
+		},
+		{
+			name: "length over the limit",
+			input: concat(corrupt(first, func(b []byte) {
+				binary.LittleEndian.PutUint32(b[lengthAt:], 1<<20)
+			}), second),
+			errs: []error{gsplug.ErrFrameTooLarge},
+		},
+		{
+			name:  "truncated frame",
+			input: concat(first[:len(first)-3], second),
+			errs:  []error{gsplug.ErrCorruptFrame},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gsplug.NewFrameReader(bytes.NewReader(tt.input))
+			for _, want := range tt.errs {
+				_, _, err := framer.ReadMessage(r)
+				if !errors.Is(err, want) {
+					t.Fatalf("got error %v, want %v", err, want)
+				}
+				if !gsplug.Recoverable(err) {
+					t.Fatalf("error %v is not recoverable", err)
+				}
+			}
+
+			_, msg, err := framer.ReadMessage(r)
+			if err != nil {
+				t.Fatalf("failed to read the frame after: %v", err)
+			}
+			if got := msg.(*pb.CommandRequest).Command; got != "second" {
+				t.Fatalf("read command %q, want %q", got, "second")
+			}
+			if _, _, err := framer.ReadMessage(r); !errors.Is(err, io.EOF) {
+				t.Fatalf("got %v at the end of the stream, want EOF", err)
+			}
+		})
+	}
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func TestLegacyFrameTooLargeDesynchronizes(t *testing.T) {
+	framer := gsplug.Framer{MaxFrameSize: 16}
+	input := encode(t, gsplug.Framer{}, command("a command longer than sixteen bytes"))
+
+	_, _, err := framer.ReadMessage(bytes.NewReader(input))
+	if !errors.Is(err, gsplug.ErrFrameTooLarge) || !errors.Is(err, gsplug.ErrDesynchronized) {
+		t.Fatalf("got %v, want a desynchronizing ErrFrameTooLarge", err)
+	}
+	if gsplug.Recoverable(err) {
+		t.Fatal("a legacy frame over the limit must not be recoverable")
+	}
+}
+
+func TestRecoverable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{gsplug.ErrCorruptFrame, true},
+		{fmt.Errorf("%w: checksum mismatch", gsplug.ErrCorruptFrame), true},
+		{gsplug.ErrFrameTooLarge, true},
+		{fmt.Errorf("%w: %w", gsplug.ErrDesynchronized, gsplug.ErrFrameTooLarge), false},
+		{gsplug.ErrDesynchronized, false},
+		{io.EOF, false},
+		{fmt.Errorf("failed to read frame data: %w", io.ErrUnexpectedEOF), false},
+		{errors.New("broken pipe"), false},
+	}
+	for _, tt := range tests {
+		if got := gsplug.Recoverable(tt.err); got != tt.want {
+			t.Errorf("Recoverable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestJSONCodecSkipsBadLines(t *testing.T) {
+	codec := gsplug.JSONCodec{MaxLineSize: 128}
+	input := concat(
+		[]byte("not json\n"),
+		[]byte("{\"type\":\"nope\"}\n"),
+		[]byte("{\"type\":\"command\",\"command\":\""+string(bytes.Repeat([]byte("x"), 200))+"\"}\n"),
+		[]byte("\n"),
+		encode(t, codec, command("second")),
+	)
+	r := bytes.NewReader(input)
+	for _, want := range []error{gsplug.ErrCorruptFrame, gsplug.ErrCorruptFrame, gsplug.ErrFrameTooLarge} {
+		if _, _, err := codec.ReadMessage(r); !errors.Is(err, want) || !gsplug.Recoverable(err) {
+			t.Fatalf("got %v, want recoverable %v", err, want)
+		}
+	}
+	msgType, msg, err := codec.ReadMessage(r)
+	if err != nil {
+		t.Fatalf("failed to read the line after: %v", err)
+	}
+	if msgType != gsplug.MessageTypeCommand || msg.(*pb.CommandRequest).Command != "second" {
+		t.Fatalf("read %d %v, want the second command", msgType, msg)
+	}
+}
@@ -0,0 +1,78 @@
+package gsplug
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+// Host callback methods. The part before the dot is the capability the
+// plugin must be granted to call them.
+const (
+	MethodListRepositories = "repositories.list"
+	MethodGetSecret        = "secrets.get"
+)
+
+// ErrPermissionDenied is returned by CallHost when the host refused a
+// callback because the plugin lacks the capability.
+var ErrPermissionDenied = errors.New("permission denied")
+
+// CallHost invokes a host callback while a request is being handled and
+// returns its raw result. It must be called from the goroutine running
+// RunPlugin, since the reply arrives on the same stdin stream.
+func CallHost(method string, parameters map[string]string) ([]byte, error) {
+	err := WriteMessage(os.Stdout, &pb.HostCallRequest{
+		Method:     method,
+		Parameters: parameters,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send host call: %w", err)
+	}
+
+	msgType, msg, err := ReadMessage(hostInput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read host call response: %w", err)
+	}
+	if msgType != MessageTypeHostCall {
+		return nil, fmt.Errorf("unexpected message type %d while waiting for host call response", msgType)
+	}
+
+	response := msg.(*pb.HostCallResponse)
+	if !response.Success {
+		if response.ErrorMessage == ErrPermissionDenied.Error() {
+			return nil, fmt.Errorf("%s: %w", method, ErrPermissionDenied)
+		}
+		return nil, fmt.Errorf("%s: %s", method, response.ErrorMessage)
+	}
+	return response.Result, nil
+}
+
+// ListRepositories asks the host for the repositories it manages. Requires
+// the repositories capability.
+func ListRepositories() ([]string, error) {
+	result, err := CallHost(MethodListRepositories, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var repositories []string
+	if err := json.Unmarshal(result, &repositories); err != nil {
+		return nil, fmt.Errorf("failed to decode repositories: %w", err)
+	}
+	return repositories, nil
+}
+
+// RequestSecret asks the host for a single secret and stores it for later
+// GetSecret calls. Requires the secrets capability.
+func RequestSecret(name string) (Secret, error) {
+	result, err := CallHost(MethodGetSecret, map[string]string{"name": name})
+	if err != nil {
+		return Secret{}, err
+	}
+
+	SetSecrets(map[string]string{name: string(result)})
+	return NewSecret(name, string(result)), nil
+}
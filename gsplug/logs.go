@@ -0,0 +1,61 @@
+package gsplug
+
+import (
+	"os"
+	"sync"
+
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+// LogsEnv is set to LogsForward by hosts that accept LogRecord messages.
+// Plugins must not send log records to a host that did not set it.
+const (
+	LogsEnv     = "GITSPACE_PLUGIN_LOGS"
+	LogsForward = "forward"
+)
+
+// LogForwardingEnabled reports whether the host asked for log records.
+func LogForwardingEnabled() bool {
+	return os.Getenv(LogsEnv) == LogsForward
+}
+
+// SendLogRecord sends a log record to the host. Unlike responses it may be
+// called from any goroutine.
+func SendLogRecord(record *pb.LogRecord) error {
+	return WriteMessage(os.Stdout, record)
+}
+
+var (
+	flushMu  sync.Mutex
+	logFlush *func()
+)
+
+// SetLogFlusher installs flush, which RunPlugin calls before writing each
+// response so that records logged while handling a request reach the host
+// before its response. It returns a function that removes flush again,
+// unless another one replaced it since. Loggers that forward records from
+// a queue install one.
+func SetLogFlusher(flush func()) (remove func()) {
+	installed := &flush
+	flushMu.Lock()
+	logFlush = installed
+	flushMu.Unlock()
+
+	return func() {
+		flushMu.Lock()
+		defer flushMu.Unlock()
+		if logFlush == installed {
+			logFlush = nil
+		}
+	}
+}
+
+// flushLogs runs the installed log flusher, if any.
+func flushLogs() {
+	flushMu.Lock()
+	flush := logFlush
+	flushMu.Unlock()
+	if flush != nil {
+		(*flush)()
+	}
+}
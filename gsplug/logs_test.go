@@ -0,0 +1,87 @@
+package gsplug_test
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+func TestRunPluginFlushesLogsBeforeResponses(t *testing.T) {
+	// The flusher stands in for a logger's queue, sending what it holds.
+	flushes := 0
+	remove := gsplug.SetLogFlusher(func() {
+		flushes++
+		gsplug.SendLogRecord(&pb.LogRecord{Message: "queued"})
+	})
+	defer remove()
+
+	msgs := runPlugin(t, gsplug.Framer{}, reposPlugin{}, encode(t, gsplug.Framer{}, &pb.PluginInfoRequest{}, &pb.MenuRequest{}))
+	if flushes != 2 || len(msgs) != 4 {
+		t.Fatalf("flushed %d times and wrote %v", flushes, msgs)
+	}
+	for i, msg := range msgs {
+		_, isLog := msg.(*pb.LogRecord)
+		if isLog != (i%2 == 0) {
+			t.Fatalf("message %d is %T, want log records before each response", i, msg)
+		}
+	}
+}
+
+func TestRunPluginSwitchesCodecWhileForwarding(t *testing.T) {
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin, stdout, defaultCodec := os.Stdin, os.Stdout, gsplug.DefaultCodec
+	os.Stdin, os.Stdout, gsplug.DefaultCodec = stdinR, stdoutW, gsplug.Framer{}
+	t.Cleanup(func() {
+		os.Stdin, os.Stdout, gsplug.DefaultCodec = stdin, stdout, defaultCodec
+		stdinR.Close()
+		stdoutR.Close()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		gsplug.RunPlugin(reposPlugin{})
+		stdoutW.Close()
+	}()
+	// Records are forwarded from other goroutines while RunPlugin picks the
+	// codec from the first byte; the race detector flags unguarded access.
+	started, stop, forwarded := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				gsplug.WriteMessage(io.Discard, &pb.LogRecord{Message: "tick"})
+			}
+			if i == 0 {
+				close(started)
+			}
+		}
+	}()
+	<-started
+
+	if _, err := stdinW.Write(encode(t, gsplug.JSONCodec{}, &pb.MenuRequest{})); err != nil {
+		t.Fatal(err)
+	}
+	_, msg, err := gsplug.JSONCodec{}.ReadPluginMessage(stdoutR)
+	close(stop)
+	<-forwarded
+	stdinW.Close()
+	<-done
+
+	if _, ok := msg.(*pb.MenuResponse); err != nil || !ok {
+		t.Fatalf("plugin answered %v, %v, want a JSON menu response", msg, err)
+	}
+}
@@ -0,0 +1,105 @@
+package gsplug
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ManifestFileName is the name of the manifest shipped next to a plugin binary.
+const ManifestFileName = "gitspace-plugin.toml"
+
+// Manifest mirrors the contents of gitspace-plugin.toml.
+type Manifest struct {
+	Metadata    ManifestMetadata `toml:"metadata"`
+	Sources     []ManifestSource `toml:"sources"`
+	Events      ManifestEvents   `toml:"events"`
+	Secrets     []ManifestSecret `toml:"secrets"`
+	Permissions Permissions      `toml:"permissions"`
+	// Assets are files installed next to the binary, as paths or glob
+	// patterns relative to the manifest.
+	Assets []string `toml:"assets"`
+	// Platforms lists the os/arch pairs the plugin ships binaries for. When
+	// set, each binary is installed under PlatformsDir and the host picks
+	// the one for the platform it runs on.
+	Platforms []Platform `toml:"platforms"`
+}
+
+// PlatformsDir is the directory of an installed plugin holding one
+// subdirectory per platform, named by Platform.Dir.
+const PlatformsDir = "bin"
+
+// Supports reports whether the manifest lists the platform.
+func (m *Manifest) Supports(platform Platform) bool {
+	for _, p := range m.Platforms {
+		if p == platform {
+			return true
+		}
+	}
+	return false
+}
+
+type ManifestMetadata struct {
+	Name        string `toml:"name"`
+	Version     string `toml:"version"`
+	Description string `toml:"description"`
+}
+
+type ManifestSource struct {
+	Path       string `toml:"path"`
+	EntryPoint string `toml:"entry_point"`
+}
+
+// ManifestEvents declares the events a plugin wants delivered without the
+// host having to ask it at startup.
+type ManifestEvents struct {
+	Subscribe []EventType `toml:"subscribe"`
+	// TickInterval controls how often schedule.tick is delivered, as a Go
+	// duration string such as "15m".
+	TickInterval string `toml:"tick_interval"`
+}
+
+// ManifestSecret declares a secret the host must deliver to the plugin, such
+// as an API token. Command parameters with the same name are redacted from
+// SDK logs.
+type ManifestSecret struct {
+	Name        string `toml:"name"`
+	Description string `toml:"description"`
+	Required    bool   `toml:"required"`
+}
+
+func LoadManifest(path string) (*Manifest, error) {
+	var manifest Manifest
+	if _, err := toml.DecodeFile(path, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest %s: %w", path, err)
+	}
+
+	for _, eventType := range manifest.Events.Subscribe {
+		if !IsKnownEventType(eventType) {
+			return nil, fmt.Errorf("manifest %s subscribes to unknown event %q", path, eventType)
+		}
+	}
+
+	if interval := manifest.Events.TickInterval; interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return nil, fmt.Errorf("manifest %s has an invalid tick_interval: %w", path, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("manifest %s has a tick_interval of %s, want a positive duration", path, interval)
+		}
+	}
+
+	for _, secret := range manifest.Secrets {
+		if secret.Name == "" {
+			return nil, fmt.Errorf("manifest %s declares a secret without a name", path)
+		}
+	}
+
+	if err := manifest.Permissions.validate(); err != nil {
+		return nil, fmt.Errorf("manifest %s: %w", path, err)
+	}
+
+	return &manifest, nil
+}
@@ -0,0 +1,58 @@
+package gsplug_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+)
+
+func TestLoadManifest(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		error    string
+	}{
+		{
+			name: "valid",
+			manifest: `
+[metadata]
+name = "hello"
+
+[events]
+subscribe = ["repo.synced", "schedule.tick"]
+tick_interval = "15m"
+
+[permissions]
+callbacks = ["repositories"]
+`,
+		},
+		{name: "no tick interval", manifest: "[events]\nsubscribe = [\"schedule.tick\"]\n"},
+		{name: "unknown event", manifest: "[events]\nsubscribe = [\"repo.renamed\"]\n", error: `unknown event "repo.renamed"`},
+		{name: "bad tick interval", manifest: "[events]\ntick_interval = \"15 minutes\"\n", error: "invalid tick_interval"},
+		{name: "negative tick interval", manifest: "[events]\ntick_interval = \"-1m\"\n", error: "positive duration"},
+		{name: "unnamed secret", manifest: "[[secrets]]\ndescription = \"token\"\n", error: "without a name"},
+		{name: "unknown callback", manifest: "[permissions]\ncallbacks = [\"email\"]\n", error: "email"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), gsplug.ManifestFileName)
+			if err := os.WriteFile(path, []byte(tt.manifest), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := gsplug.LoadManifest(path)
+			if tt.error == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Fatalf("got error %v, want %q", err, tt.error)
+			}
+		})
+	}
+}
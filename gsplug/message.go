@@ -0,0 +1,123 @@
+package gsplug
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// ReadMessage reads a message sent by the host with DefaultCodec. Plugins
+// use it to receive requests. It does not detect the codec from the input;
+// RunPlugin does, so plugins should prefer it to their own read loop.
+func ReadMessage(r io.Reader) (uint32, proto.Message, error) {
+	return DefaultCodec.ReadMessage(r)
+}
+
+// ReadPluginMessage reads a message sent by a plugin with DefaultCodec.
+// Hosts use it to receive responses and host calls.
+func ReadPluginMessage(r io.Reader) (uint32, proto.Message, error) {
+	return DefaultCodec.ReadPluginMessage(r)
+}
+
+// writeMu serializes WriteMessage so that log records forwarded from other
+// goroutines never interleave with responses.
+var writeMu sync.Mutex
+
+// WriteMessage writes a message in either direction with DefaultCodec; the
+// message type is derived from the message. It is safe for concurrent use.
+func WriteMessage(w io.Writer, msg proto.Message) error {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	return DefaultCodec.WriteMessage(w, msg)
+}
+
+// newHostMessage returns an empty message of a type the host sends.
+func newHostMessage(msgType uint32) (proto.Message, error) {
+	switch msgType {
+	case MessageTypePluginInfo:
+		return &pb.PluginInfoRequest{}, nil
+	case MessageTypeCommand:
+		return &pb.CommandRequest{}, nil
+	case MessageTypeMenu:
+		return &pb.MenuRequest{}, nil
+	case MessageTypeSubscriptions:
+		return &pb.SubscriptionRequest{}, nil
+	case MessageTypeEvent:
+		return &pb.Event{}, nil
+	case MessageTypeConfig:
+		return &pb.ConfigRequest{}, nil
+	case MessageTypeUpdateConfig:
+		return &pb.UpdateConfigRequest{}, nil
+	case MessageTypeSecrets:
+		return &pb.SecretsRequest{}, nil
+	case MessageTypeHostCall:
+		return &pb.HostCallResponse{}, nil
+	case MessageTypeChunk:
+		return &pb.ChunkAck{}, nil
+	default:
+		return nil, fmt.Errorf("unknown message type: %d", msgType)
+	}
+}
+
+// newPluginMessage returns an empty message of a type a plugin sends.
+func newPluginMessage(msgType uint32) (proto.Message, error) {
+	switch msgType {
+	case MessageTypePluginInfo:
+		return &pb.PluginInfo{}, nil
+	case MessageTypeCommand:
+		return &pb.CommandResponse{}, nil
+	case MessageTypeMenu:
+		return &pb.MenuResponse{}, nil
+	case MessageTypeSubscriptions:
+		return &pb.SubscriptionResponse{}, nil
+	case MessageTypeEvent:
+		return &pb.EventResponse{}, nil
+	case MessageTypeConfig:
+		return &pb.ConfigResponse{}, nil
+	case MessageTypeUpdateConfig:
+		return &pb.UpdateConfigResponse{}, nil
+	case MessageTypeSecrets:
+		return &pb.SecretsResponse{}, nil
+	case MessageTypeHostCall:
+		return &pb.HostCallRequest{}, nil
+	case MessageTypeChunk:
+		return &pb.Chunk{}, nil
+	case MessageTypeLog:
+		return &pb.LogRecord{}, nil
+	default:
+		return nil, fmt.Errorf("unknown message type: %d", msgType)
+	}
+}
+
+// messageType returns the frame type used to send msg.
+func messageType(msg proto.Message) (uint8, error) {
+	switch msg.(type) {
+	case *pb.PluginInfoRequest, *pb.PluginInfo:
+		return MessageTypePluginInfo, nil
+	case *pb.CommandRequest, *pb.CommandResponse:
+		return MessageTypeCommand, nil
+	case *pb.MenuRequest, *pb.MenuResponse:
+		return MessageTypeMenu, nil
+	case *pb.SubscriptionRequest, *pb.SubscriptionResponse:
+		return MessageTypeSubscriptions, nil
+	case *pb.Event, *pb.EventResponse:
+		return MessageTypeEvent, nil
+	case *pb.ConfigRequest, *pb.ConfigResponse:
+		return MessageTypeConfig, nil
+	case *pb.UpdateConfigRequest, *pb.UpdateConfigResponse:
+		return MessageTypeUpdateConfig, nil
+	case *pb.SecretsRequest, *pb.SecretsResponse:
+		return MessageTypeSecrets, nil
+	case *pb.HostCallRequest, *pb.HostCallResponse:
+		return MessageTypeHostCall, nil
+	case *pb.Chunk, *pb.ChunkAck:
+		return MessageTypeChunk, nil
+	case *pb.LogRecord:
+		return MessageTypeLog, nil
+	default:
+		return 0, fmt.Errorf("unknown message type: %T", msg)
+	}
+}
@@ -0,0 +1,151 @@
+package gsplug
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Capability names a host callback group a plugin may be granted.
+type Capability string
+
+const (
+	CapabilityRepositories Capability = "repositories"
+	CapabilitySecrets      Capability = "secrets"
+)
+
+// KnownCapabilities lists every host callback group.
+var KnownCapabilities = []Capability{
+	CapabilityRepositories,
+	CapabilitySecrets,
+}
+
+// FilesystemAccess is the access level requested for a filesystem path.
+type FilesystemAccess string
+
+const (
+	AccessRead      FilesystemAccess = "read"
+	AccessReadWrite FilesystemAccess = "write"
+)
+
+// Permissions declares what a plugin intends to do, as listed in the
+// [permissions] table of gitspace-plugin.toml.
+type Permissions struct {
+	Filesystem []FilesystemPermission `toml:"filesystem" json:"filesystem,omitempty"`
+	Network    []string               `toml:"network" json:"network,omitempty"`
+	Exec       []string               `toml:"exec" json:"exec,omitempty"`
+	Callbacks  []Capability           `toml:"callbacks" json:"callbacks,omitempty"`
+}
+
+type FilesystemPermission struct {
+	Path   string           `toml:"path" json:"path"`
+	Access FilesystemAccess `toml:"access" json:"access"`
+}
+
+// HasCallback reports whether capability is among the declared callbacks.
+func (p Permissions) HasCallback(capability Capability) bool {
+	for _, declared := range p.Callbacks {
+		if declared == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether p grants everything other requests.
+func (p Permissions) Covers(other Permissions) bool {
+	for _, capability := range other.Callbacks {
+		if !p.HasCallback(capability) {
+			return false
+		}
+	}
+	for _, host := range other.Network {
+		if !contains(p.Network, host) {
+			return false
+		}
+	}
+	for _, binary := range other.Exec {
+		if !contains(p.Exec, binary) {
+			return false
+		}
+	}
+	for _, requested := range other.Filesystem {
+		covered := false
+		for _, granted := range p.Filesystem {
+			if granted.Path == requested.Path && (granted.Access == AccessReadWrite || requested.Access != AccessReadWrite) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// IsEmpty reports whether no permissions are declared.
+func (p Permissions) IsEmpty() bool {
+	return len(p.Filesystem) == 0 && len(p.Network) == 0 && len(p.Exec) == 0 && len(p.Callbacks) == 0
+}
+
+// Describe returns one human-readable line per permission, suitable for an
+// approval prompt.
+func (p Permissions) Describe() []string {
+	var lines []string
+	for _, fs := range p.Filesystem {
+		verb := "Read"
+		if fs.Access == AccessReadWrite {
+			verb = "Read and write"
+		}
+		lines = append(lines, fmt.Sprintf("%s files under %s", verb, fs.Path))
+	}
+	for _, host := range p.Network {
+		lines = append(lines, fmt.Sprintf("Connect to %s", host))
+	}
+	for _, binary := range p.Exec {
+		lines = append(lines, fmt.Sprintf("Run %s", binary))
+	}
+	for _, capability := range p.Callbacks {
+		lines = append(lines, fmt.Sprintf("Use the Gitspace %s callbacks", capability))
+	}
+	return lines
+}
+
+func (p Permissions) validate() error {
+	for _, fs := range p.Filesystem {
+		if fs.Path == "" {
+			return fmt.Errorf("filesystem permission without a path")
+		}
+		if fs.Access != AccessRead && fs.Access != AccessReadWrite {
+			return fmt.Errorf("filesystem permission for %s has unknown access %q", fs.Path, fs.Access)
+		}
+	}
+	for _, capability := range p.Callbacks {
+		known := false
+		for _, k := range KnownCapabilities {
+			if k == capability {
+				known = true
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown callback capability %q", capability)
+		}
+	}
+	return nil
+}
+
+// CallbackCapability returns the capability required to invoke a host
+// callback method such as "repositories.list".
+func CallbackCapability(method string) Capability {
+	capability, _, _ := strings.Cut(method, ".")
+	return Capability(capability)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package gsplug
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Platform is a GOOS/GOARCH pair a plugin binary is built for.
+type Platform struct {
+	OS   string
+	Arch string
+}
+
+// CurrentPlatform is the platform this program runs on.
+var CurrentPlatform = Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+
+// ParsePlatform parses "os/arch", as printed by go tool dist list.
+func ParsePlatform(s string) (Platform, error) {
+	goos, goarch, ok := strings.Cut(s, "/")
+	if !ok || goos == "" || goarch == "" || strings.Contains(goarch, "/") {
+		return Platform{}, fmt.Errorf("invalid platform %q, expected os/arch", s)
+	}
+	return Platform{OS: goos, Arch: goarch}, nil
+}
+
+func (p Platform) String() string {
+	return p.OS + "/" + p.Arch
+}
+
+// Dir is the directory holding the platform's binary in a build or an
+// installed multi-platform plugin, e.g. "linux_amd64".
+func (p Platform) Dir() string {
+	return p.OS + "_" + p.Arch
+}
+
+// BinaryName returns the file name of the plugin binary on the platform.
+func (p Platform) BinaryName(name string) string {
+	if p.OS == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
+
+func (p Platform) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *Platform) UnmarshalText(text []byte) error {
+	platform, err := ParsePlatform(string(text))
+	if err != nil {
+		return err
+	}
+	*p = platform
+	return nil
+}
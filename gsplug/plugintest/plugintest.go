@@ -0,0 +1,108 @@
+// Package plugintest drives a plugin handler from tests the way the host
+// does, without starting a process.
+package plugintest
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// Harness sends requests to a handler through the wire encoding, so
+// messages that would not survive the trip to the host fail the test.
+// Failures to encode, decode or handle a request are reported with
+// t.Fatal; unsuccessful responses are returned for the test to check.
+type Harness struct {
+	t       testing.TB
+	handler gsplug.PluginHandler
+	codec   gsplug.Codec
+}
+
+func New(t testing.TB, handler gsplug.PluginHandler) *Harness {
+	return &Harness{t: t, handler: handler, codec: gsplug.Framer{}}
+}
+
+// WithCodec returns a harness that encodes messages with codec instead of
+// the protobuf framing.
+func (h *Harness) WithCodec(codec gsplug.Codec) *Harness {
+	return &Harness{t: h.t, handler: h.handler, codec: codec}
+}
+
+// Send delivers req to the handler and returns its response.
+func (h *Harness) Send(req proto.Message) proto.Message {
+	h.t.Helper()
+
+	var wire bytes.Buffer
+	if err := h.codec.WriteMessage(&wire, req); err != nil {
+		h.t.Fatalf("failed to encode request: %v", err)
+	}
+	msgType, msg, err := h.codec.ReadMessage(&wire)
+	if err != nil {
+		h.t.Fatalf("failed to decode request: %v", err)
+	}
+
+	response, err := gsplug.HandleMessage(h.handler, msgType, msg)
+	if err != nil {
+		h.t.Fatalf("handler failed: %v", err)
+	}
+	if response == nil {
+		h.t.Fatalf("handler returned no response to %T", req)
+	}
+
+	wire.Reset()
+	if err := h.codec.WriteMessage(&wire, response); err != nil {
+		h.t.Fatalf("failed to encode response: %v", err)
+	}
+	_, decoded, err := h.codec.ReadPluginMessage(&wire)
+	if err != nil {
+		h.t.Fatalf("failed to decode response: %v", err)
+	}
+	return decoded
+}
+
+func (h *Harness) Info() *pb.PluginInfo {
+	h.t.Helper()
+	return h.Send(&pb.PluginInfoRequest{}).(*pb.PluginInfo)
+}
+
+// Menu returns the decoded menu.
+func (h *Harness) Menu() []gsplug.MenuOption {
+	h.t.Helper()
+
+	response := h.Send(&pb.MenuRequest{}).(*pb.MenuResponse)
+	var menu []gsplug.MenuOption
+	if err := json.Unmarshal(response.MenuData, &menu); err != nil {
+		h.t.Fatalf("failed to decode menu: %v", err)
+	}
+	return menu
+}
+
+func (h *Harness) Execute(command string, parameters map[string]string) *pb.CommandResponse {
+	h.t.Helper()
+	return h.Send(&pb.CommandRequest{Command: command, Parameters: parameters}).(*pb.CommandResponse)
+}
+
+func (h *Harness) Subscriptions() []gsplug.EventType {
+	h.t.Helper()
+
+	response := h.Send(&pb.SubscriptionRequest{}).(*pb.SubscriptionResponse)
+	events := make([]gsplug.EventType, len(response.Events))
+	for i, event := range response.Events {
+		events[i] = gsplug.EventType(event)
+	}
+	return events
+}
+
+// Event delivers an event of eventType with the given attributes.
+func (h *Harness) Event(eventType gsplug.EventType, repository string, attributes map[string]string) *pb.EventResponse {
+	h.t.Helper()
+	return h.Send(&pb.Event{
+		Type:       string(eventType),
+		Repository: repository,
+		Attributes: attributes,
+	}).(*pb.EventResponse)
+}
@@ -0,0 +1,77 @@
+package gsplug
+
+import (
+	"context"
+	"sync"
+
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+// RequestHook runs around every command HandleMessage dispatches. It is
+// given the request and the version from the last PluginInfo the plugin
+// answered, and returns the context the command runs with and a function
+// called with the command's result. The logger package installs one that
+// logs when each request starts and finishes.
+type RequestHook func(ctx context.Context, req *pb.CommandRequest, version string) (context.Context, func(*pb.CommandResponse, error))
+
+// ContextCommandHandler is implemented by handlers, such as CommandRouter,
+// that run commands with the context returned by the RequestHook.
+type ContextCommandHandler interface {
+	ExecuteCommandContext(context.Context, *pb.CommandRequest) (*pb.CommandResponse, error)
+}
+
+var (
+	hookMu        sync.RWMutex
+	requestHook   *RequestHook
+	pluginVersion string
+)
+
+// SetRequestHook installs hook in place of any previous one and returns a
+// function that removes it again, unless another hook replaced it since.
+func SetRequestHook(hook RequestHook) (remove func()) {
+	installed := &hook
+	hookMu.Lock()
+	requestHook = installed
+	hookMu.Unlock()
+
+	return func() {
+		hookMu.Lock()
+		defer hookMu.Unlock()
+		if requestHook == installed {
+			requestHook = nil
+		}
+	}
+}
+
+// executeCommand runs req through the request hook, if one is installed.
+func executeCommand(handler PluginHandler, req *pb.CommandRequest) (*pb.CommandResponse, error) {
+	hookMu.RLock()
+	hook, version := requestHook, pluginVersion
+	hookMu.RUnlock()
+
+	ctx := context.Background()
+	done := func(*pb.CommandResponse, error) {}
+	if hook != nil {
+		ctx, done = (*hook)(ctx, req, version)
+	}
+
+	var response *pb.CommandResponse
+	var err error
+	if h, ok := handler.(ContextCommandHandler); ok {
+		response, err = h.ExecuteCommandContext(ctx, req)
+	} else {
+		response, err = handler.ExecuteCommand(req)
+	}
+	done(response, err)
+	return response, err
+}
+
+// rememberVersion records the version request hooks are given.
+func rememberVersion(info *pb.PluginInfo) {
+	if info == nil || info.Version == "" {
+		return
+	}
+	hookMu.Lock()
+	pluginVersion = info.Version
+	hookMu.Unlock()
+}
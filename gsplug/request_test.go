@@ -0,0 +1,87 @@
+package gsplug_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+type ctxKey struct{}
+
+// infoPlugin embeds a router and reports version 2.0.0.
+type infoPlugin struct {
+	*gsplug.CommandRouter
+}
+
+func (infoPlugin) GetPluginInfo(*pb.PluginInfoRequest) (*pb.PluginInfo, error) {
+	return &pb.PluginInfo{Name: "info", Version: "2.0.0"}, nil
+}
+
+func TestRequestHook(t *testing.T) {
+	var version string
+	var finished *pb.CommandResponse
+	remove := gsplug.SetRequestHook(func(ctx context.Context, req *pb.CommandRequest, v string) (context.Context, func(*pb.CommandResponse, error)) {
+		version = v
+		req.RequestId = "req-1"
+		return context.WithValue(ctx, ctxKey{}, req.Command), func(response *pb.CommandResponse, err error) {
+			finished = response
+		}
+	})
+	defer remove()
+
+	router := gsplug.NewCommandRouter()
+	router.CommandContext("Hooked", "hooked", func(ctx context.Context, req *pb.CommandRequest) (*pb.CommandResponse, error) {
+		command, _ := ctx.Value(ctxKey{}).(string)
+		return &pb.CommandResponse{Success: true, Result: command}, nil
+	})
+	plugin := infoPlugin{router}
+
+	if _, err := gsplug.HandleMessage(plugin, gsplug.MessageTypePluginInfo, &pb.PluginInfoRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := gsplug.HandleMessage(plugin, gsplug.MessageTypeCommand, &pb.CommandRequest{Command: "hooked"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := msg.(*pb.CommandResponse)
+	if response.Result != "hooked" || response.RequestId != "req-1" {
+		t.Fatalf("command answered %v, want the hook's context and request ID", response)
+	}
+	if version != "2.0.0" || finished != response {
+		t.Fatalf("hook saw version %q and finished with %v", version, finished)
+	}
+
+	remove()
+	finished = nil
+	if _, err := gsplug.HandleMessage(plugin, gsplug.MessageTypeCommand, &pb.CommandRequest{Command: "hooked"}); err != nil || finished != nil {
+		t.Fatalf("removed hook still ran: %v, %v", finished, err)
+	}
+}
+
+func TestRemoveReplacedRequestHook(t *testing.T) {
+	var calls []string
+	hook := func(name string) gsplug.RequestHook {
+		return func(ctx context.Context, req *pb.CommandRequest, version string) (context.Context, func(*pb.CommandResponse, error)) {
+			calls = append(calls, name)
+			return ctx, func(*pb.CommandResponse, error) {}
+		}
+	}
+	removeFirst := gsplug.SetRequestHook(hook("first"))
+	removeSecond := gsplug.SetRequestHook(hook("second"))
+	defer removeSecond()
+	// Removing a replaced hook leaves its replacement installed.
+	removeFirst()
+
+	if _, err := gsplug.HandleMessage(reposPlugin{}, gsplug.MessageTypeMenu, &pb.MenuRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	router := gsplug.NewCommandRouter()
+	if _, err := gsplug.HandleMessage(infoPlugin{router}, gsplug.MessageTypeCommand, &pb.CommandRequest{Command: "missing"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 || calls[0] != "second" {
+		t.Fatalf("hooks called: %v", calls)
+	}
+}
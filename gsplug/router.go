@@ -0,0 +1,195 @@
+package gsplug
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+// CommandFunc runs a single command. Parameters have been checked against
+// the command's ParameterInfo and defaults filled in.
+type CommandFunc func(*pb.CommandRequest) (*pb.CommandResponse, error)
+
+// ContextCommandFunc is a CommandFunc that also receives the request's
+// context, which carries whatever the router's middleware attached to it,
+// such as a request-scoped logger.
+type ContextCommandFunc func(context.Context, *pb.CommandRequest) (*pb.CommandResponse, error)
+
+// Middleware wraps the dispatch of every command a router runs, including
+// unknown commands and those rejected for invalid parameters.
+type Middleware func(next ContextCommandFunc) ContextCommandFunc
+
+// CommandRouter builds a plugin's menu and dispatches commands to the
+// functions registered for them. Embedding a *CommandRouter in a plugin
+// provides ExecuteCommand and GetMenu, leaving only GetPluginInfo. RunPlugin
+// calls ExecuteCommandContext, so a plugin that wraps ExecuteCommand must
+// wrap it too.
+type CommandRouter struct {
+	routes *routes
+	node   *menuNode
+}
+
+// routes is shared by a router and its groups.
+type routes struct {
+	mu         sync.RWMutex
+	handlers   map[string]ContextCommandFunc
+	middleware []Middleware
+}
+
+type menuNode struct {
+	option   MenuOption
+	children []*menuNode
+}
+
+func NewCommandRouter() *CommandRouter {
+	return &CommandRouter{
+		routes: &routes{handlers: make(map[string]ContextCommandFunc)},
+		node:   &menuNode{},
+	}
+}
+
+// Command adds a menu entry that runs fn.
+func (r *CommandRouter) Command(label, command string, fn CommandFunc, parameters ...ParameterInfo) {
+	r.CommandContext(label, command, func(ctx context.Context, req *pb.CommandRequest) (*pb.CommandResponse, error) {
+		return fn(req)
+	}, parameters...)
+}
+
+// CommandContext adds a menu entry that runs fn with the request's context.
+func (r *CommandRouter) CommandContext(label, command string, fn ContextCommandFunc, parameters ...ParameterInfo) {
+	r.routes.mu.Lock()
+	defer r.routes.mu.Unlock()
+
+	r.node.children = append(r.node.children, &menuNode{option: MenuOption{
+		Label:      label,
+		Command:    command,
+		Parameters: parameters,
+	}})
+	r.routes.handlers[command] = fn
+}
+
+// Group adds a sub-menu and returns a router for its entries. Commands
+// registered on the group are dispatched by the parent.
+func (r *CommandRouter) Group(label string) *CommandRouter {
+	r.routes.mu.Lock()
+	defer r.routes.mu.Unlock()
+
+	group := &menuNode{option: MenuOption{Label: label}}
+	r.node.children = append(r.node.children, group)
+	return &CommandRouter{routes: r.routes, node: group}
+}
+
+// Use adds middleware around every command the router and its groups run.
+// The first middleware added is the outermost.
+func (r *CommandRouter) Use(middleware ...Middleware) {
+	r.routes.mu.Lock()
+	defer r.routes.mu.Unlock()
+
+	r.routes.middleware = append(r.routes.middleware, middleware...)
+}
+
+// Menu returns the menu built so far.
+func (r *CommandRouter) Menu() []MenuOption {
+	r.routes.mu.RLock()
+	defer r.routes.mu.RUnlock()
+
+	return r.node.menu()
+}
+
+func (n *menuNode) menu() []MenuOption {
+	if len(n.children) == 0 {
+		return nil
+	}
+	menu := make([]MenuOption, len(n.children))
+	for i, child := range n.children {
+		menu[i] = child.option
+		menu[i].SubMenu = child.menu()
+	}
+	return menu
+}
+
+func (r *CommandRouter) GetMenu(req *pb.MenuRequest) (*pb.MenuResponse, error) {
+	menuBytes, err := json.Marshal(r.Menu())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal menu: %w", err)
+	}
+	return &pb.MenuResponse{MenuData: menuBytes}, nil
+}
+
+// ExecuteCommand checks the request's parameters and runs the command's
+// function through the router's middleware. Unknown commands and invalid
+// parameters fail the command without calling it.
+func (r *CommandRouter) ExecuteCommand(req *pb.CommandRequest) (*pb.CommandResponse, error) {
+	return r.ExecuteCommandContext(context.Background(), req)
+}
+
+// ExecuteCommandContext is ExecuteCommand with the context passed to the
+// middleware and the command's function.
+func (r *CommandRouter) ExecuteCommandContext(ctx context.Context, req *pb.CommandRequest) (*pb.CommandResponse, error) {
+	r.routes.mu.RLock()
+	middleware := r.routes.middleware
+	r.routes.mu.RUnlock()
+
+	run := r.dispatch
+	for i := len(middleware) - 1; i >= 0; i-- {
+		run = middleware[i](run)
+	}
+	return run(ctx, req)
+}
+
+func (r *CommandRouter) dispatch(ctx context.Context, req *pb.CommandRequest) (*pb.CommandResponse, error) {
+	r.routes.mu.RLock()
+	fn, ok := r.routes.handlers[req.Command]
+	r.routes.mu.RUnlock()
+	var parameters []ParameterInfo
+	if option := findMenuCommand(r.Menu(), req.Command); option != nil {
+		parameters = option.Parameters
+	}
+
+	if !ok {
+		return &pb.CommandResponse{
+			Success:      false,
+			ErrorMessage: fmt.Sprintf("unknown command %q", req.Command),
+		}, nil
+	}
+
+	if req.Parameters == nil {
+		req.Parameters = make(map[string]string)
+	}
+	for _, param := range parameters {
+		value, set := req.Parameters[param.Name]
+		if !set || value == "" {
+			if param.Default != "" {
+				req.Parameters[param.Name] = param.Default
+				continue
+			}
+			if param.Required {
+				return &pb.CommandResponse{
+					Success:      false,
+					ErrorMessage: fmt.Sprintf("missing required parameter %q", param.Name),
+				}, nil
+			}
+			continue
+		}
+		if err := param.Validate(value); err != nil {
+			return &pb.CommandResponse{Success: false, ErrorMessage: err.Error()}, nil
+		}
+	}
+
+	return fn(ctx, req)
+}
+
+func findMenuCommand(menu []MenuOption, command string) *MenuOption {
+	for i := range menu {
+		if menu[i].Command == command {
+			return &menu[i]
+		}
+		if option := findMenuCommand(menu[i].SubMenu, command); option != nil {
+			return option
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package gsplug_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug/plugintest"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+type routedPlugin struct {
+	*gsplug.CommandRouter
+	*gsplug.EventRouter
+}
+
+func (p *routedPlugin) GetPluginInfo(*pb.PluginInfoRequest) (*pb.PluginInfo, error) {
+	return &pb.PluginInfo{Name: "routed", Version: "1.0.0"}, nil
+}
+
+func newRoutedPlugin() *routedPlugin {
+	p := &routedPlugin{CommandRouter: gsplug.NewCommandRouter(), EventRouter: gsplug.NewEventRouter()}
+	p.Command("Greet", "greet", func(req *pb.CommandRequest) (*pb.CommandResponse, error) {
+		return &pb.CommandResponse{Success: true, Result: "Hello, " + req.Parameters["name"] + "!"}, nil
+	}, gsplug.ParameterInfo{Name: "name", Default: "World"})
+
+	repos := p.Group("Repositories")
+	repos.Command("Count", "count", func(req *pb.CommandRequest) (*pb.CommandResponse, error) {
+		return &pb.CommandResponse{Success: true, Result: req.Parameters["limit"]}, nil
+	}, gsplug.ParameterInfo{Name: "limit", Type: gsplug.ParameterInt, Required: true})
+	return p
+}
+
+func TestRouterMenu(t *testing.T) {
+	h := plugintest.New(t, newRoutedPlugin())
+
+	menu := h.Menu()
+	if len(menu) != 2 || menu[0].Command != "greet" || menu[1].Label != "Repositories" {
+		t.Fatalf("unexpected menu %+v", menu)
+	}
+	if sub := menu[1].SubMenu; len(sub) != 1 || sub[0].Command != "count" {
+		t.Fatalf("unexpected sub-menu %+v", sub)
+	}
+}
+
+func TestRouterParameters(t *testing.T) {
+	tests := []struct {
+		name       string
+		command    string
+		parameters map[string]string
+		result     string
+		error      string
+	}{
+		{name: "default", command: "greet", result: "Hello, World!"},
+		{name: "value", command: "greet", parameters: map[string]string{"name": "Ann"}, result: "Hello, Ann!"},
+		{name: "grouped", command: "count", parameters: map[string]string{"limit": "3"}, result: "3"},
+		{name: "missing required", command: "count", error: `missing required parameter "limit"`},
+		{name: "wrong type", command: "count", parameters: map[string]string{"limit": "many"}, error: "expects type int"},
+		{name: "unknown command", command: "nope", error: `unknown command "nope"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := plugintest.New(t, newRoutedPlugin())
+
+			response := h.Execute(tt.command, tt.parameters)
+			if tt.error != "" {
+				if response.Success || !strings.Contains(response.ErrorMessage, tt.error) {
+					t.Fatalf("got %+v, want failure %q", response, tt.error)
+				}
+				return
+			}
+			if !response.Success || response.Result != tt.result {
+				t.Fatalf("got %+v, want result %q", response, tt.result)
+			}
+		})
+	}
+}
+
+func TestRouterMiddleware(t *testing.T) {
+	p := newRoutedPlugin()
+	var calls []string
+	trace := func(name string) gsplug.Middleware {
+		return func(next gsplug.ContextCommandFunc) gsplug.ContextCommandFunc {
+			return func(ctx context.Context, req *pb.CommandRequest) (*pb.CommandResponse, error) {
+				calls = append(calls, name+" "+req.Command)
+				return next(ctx, req)
+			}
+		}
+	}
+	p.Use(trace("outer"), trace("inner"))
+	h := plugintest.New(t, p)
+
+	h.Execute("greet", nil)
+	h.Execute("nope", nil)
+	want := "outer greet,inner greet,outer nope,inner nope"
+	if got := strings.Join(calls, ","); got != want {
+		t.Fatalf("middleware ran as %s, want %s", got, want)
+	}
+}
+
+func TestEventRouter(t *testing.T) {
+	p := newRoutedPlugin()
+	var synced []string
+	p.On(gsplug.EventRepoSynced, func(event *pb.Event) error {
+		synced = append(synced, event.Repository)
+		return nil
+	})
+	p.On(gsplug.EventRepoSynced, func(event *pb.Event) error {
+		return errors.New("second handler failed")
+	})
+	h := plugintest.New(t, p)
+
+	if events := h.Subscriptions(); len(events) != 1 || events[0] != gsplug.EventRepoSynced {
+		t.Fatalf("unexpected subscriptions %v", events)
+	}
+
+	response := h.Event(gsplug.EventRepoSynced, "ssotops/gitspace", nil)
+	if response.Success || response.ErrorMessage != "second handler failed" {
+		t.Fatalf("got %+v, want the second handler's error", response)
+	}
+	if len(synced) != 1 || synced[0] != "ssotops/gitspace" {
+		t.Fatalf("first handler saw %v", synced)
+	}
+
+	if response := h.Event(gsplug.EventRepoCloned, "ssotops/gitspace", nil); response.Success {
+		t.Fatal("an event without handlers succeeded")
+	}
+}
+
+func TestRouterOverJSON(t *testing.T) {
+	h := plugintest.New(t, newRoutedPlugin()).WithCodec(gsplug.JSONCodec{})
+
+	if response := h.Execute("greet", map[string]string{"name": "JSON"}); response.Result != "Hello, JSON!" {
+		t.Fatalf("unexpected result %q", response.Result)
+	}
+}
@@ -1,17 +1,37 @@
 package gsplug
 
 import (
-	"encoding/binary"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 
-	"github.com/charmbracelet/log"
 	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
 	"google.golang.org/protobuf/proto"
 )
 
+// Frame types used on the wire. A request and its response share the same
+// type; the direction of the frame tells them apart. Host calls and chunks
+// are initiated by the plugin while it handles a request; log records are
+// sent by the plugin at any time and never answered.
+const (
+	MessageTypePluginInfo    = 1
+	MessageTypeCommand       = 2
+	MessageTypeMenu          = 3
+	MessageTypeSubscriptions = 4
+	MessageTypeEvent         = 5
+	MessageTypeConfig        = 6
+	MessageTypeUpdateConfig  = 7
+	MessageTypeSecrets       = 8
+	MessageTypeHostCall      = 9
+	MessageTypeChunk         = 10
+	MessageTypeLog           = 11
+)
+
 type PluginHandler interface {
 	GetPluginInfo(*pb.PluginInfoRequest) (*pb.PluginInfo, error)
 	ExecuteCommand(*pb.CommandRequest) (*pb.CommandResponse, error)
@@ -26,179 +46,232 @@ type MenuOption struct {
 }
 
 type ParameterInfo struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Required    bool   `json:"required"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Required    bool          `json:"required"`
+	Type        ParameterType `json:"type,omitempty"`
+	Default     string        `json:"default,omitempty"`
+}
+
+// ParameterType describes how a parameter value is interpreted. Values always
+// travel as strings; an empty type is treated as ParameterString.
+type ParameterType string
+
+const (
+	ParameterString ParameterType = "string"
+	ParameterInt    ParameterType = "int"
+	ParameterFloat  ParameterType = "float"
+	ParameterBool   ParameterType = "bool"
+)
+
+// Validate checks value against the parameter's type and Required flag.
+func (p ParameterInfo) Validate(value string) error {
+	if value == "" {
+		if p.Required {
+			return fmt.Errorf("parameter %q is required", p.Name)
+		}
+		return nil
+	}
+
+	var err error
+	switch p.Type {
+	case "", ParameterString:
+	case ParameterInt:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case ParameterFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case ParameterBool:
+		_, err = strconv.ParseBool(value)
+	default:
+		return fmt.Errorf("parameter %q has unknown type %q", p.Name, p.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("parameter %q expects type %s, got %q", p.Name, p.Type, value)
+	}
+	return nil
 }
 
+// hostInput is where the plugin reads the host's messages. RunPlugin sets it
+// to the reader returned by sniffCodec, so host call responses and chunk
+// acks are read after any bytes that reader holds.
+var hostInput io.Reader = os.Stdin
+
 func RunPlugin(handler PluginHandler) {
+	stdin, err := sniffCodec(os.Stdin)
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			fmt.Fprintf(os.Stderr, "Error reading message: %v\n", err)
+		}
+		return
+	}
+	hostInput = stdin
+
 	for {
-		msgType, msg, err := readMessage(os.Stdin)
+		msgType, msg, err := ReadMessage(stdin)
 		if err != nil {
-			if err == io.EOF {
+			if errors.Is(err, io.EOF) {
 				return
 			}
 			fmt.Fprintf(os.Stderr, "Error reading message: %v\n", err)
+			if !Recoverable(err) {
+				return
+			}
 			continue
 		}
 
-		var response proto.Message
-		var handlerErr error
-
-		switch msgType {
-		case 1:
-			req := msg.(*pb.PluginInfoRequest)
-			response, handlerErr = handler.GetPluginInfo(req)
-		default:
-			fmt.Fprintf(os.Stderr, "Unknown message type: %d\n", msgType)
-			continue
-		}
-
-		if handlerErr != nil {
-			fmt.Fprintf(os.Stderr, "Handler error: %v\n", handlerErr)
-			continue
+		response, err := HandleMessage(handler, msgType, msg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Handler error: %v\n", err)
+			response = ErrorResponse(msgType, msg, err)
+			if response == nil {
+				continue
+			}
 		}
 
-		err = writeMessage(os.Stdout, response)
+		flushLogs()
+		err = WriteMessage(os.Stdout, response)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error writing response: %v\n", err)
 		}
 	}
 }
 
-func readMessage(r io.Reader) (uint32, proto.Message, error) {
-	var msgType uint32
-	err := binary.Read(r, binary.LittleEndian, &msgType)
-	if err != nil {
-		return 0, nil, err
-	}
-
-	var msgLen uint32
-	err = binary.Read(r, binary.LittleEndian, &msgLen)
-	if err != nil {
-		return 0, nil, err
-	}
-
-	msgData := make([]byte, msgLen)
-	_, err = io.ReadFull(r, msgData)
-	if err != nil {
-		return 0, nil, err
-	}
+// NewRequestID returns a random ID for a CommandRequest that arrived
+// without one. Hosts and plugins use the same format, so a request can be
+// traced in both logs.
+func NewRequestID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
 
-	var msg proto.Message
+// HandleMessage dispatches a decoded request to the matching handler method
+// and returns the response to write back to the host.
+func HandleMessage(handler PluginHandler, msgType uint32, msg proto.Message) (proto.Message, error) {
 	switch msgType {
-	case 1:
-		msg = &pb.PluginInfoRequest{}
+	case MessageTypePluginInfo:
+		info, err := handler.GetPluginInfo(msg.(*pb.PluginInfoRequest))
+		if err == nil {
+			rememberVersion(info)
+		}
+		return info, err
+	case MessageTypeCommand:
+		req := msg.(*pb.CommandRequest)
+		response, err := executeCommand(handler, req)
+		if response != nil && response.RequestId == "" {
+			response.RequestId = req.RequestId
+		}
+		return response, err
+	case MessageTypeMenu:
+		return handler.GetMenu(msg.(*pb.MenuRequest))
+	case MessageTypeSubscriptions:
+		subscriber, ok := handler.(EventSubscriber)
+		if !ok {
+			return &pb.SubscriptionResponse{}, nil
+		}
+		return subscriber.GetSubscriptions(msg.(*pb.SubscriptionRequest))
+	case MessageTypeEvent:
+		eventHandler, ok := handler.(EventHandler)
+		if !ok {
+			return &pb.EventResponse{
+				Success:      false,
+				ErrorMessage: "plugin does not handle events",
+			}, nil
+		}
+		return eventHandler.HandleEvent(msg.(*pb.Event))
+	case MessageTypeConfig:
+		if provider, ok := handler.(ConfigProvider); ok {
+			return provider.PluginConfig().describe()
+		}
+		if configHandler, ok := handler.(ConfigHandler); ok {
+			return configHandler.GetConfig(msg.(*pb.ConfigRequest))
+		}
+		return &pb.ConfigResponse{}, nil
+	case MessageTypeUpdateConfig:
+		if provider, ok := handler.(ConfigProvider); ok {
+			return provider.PluginConfig().update(msg.(*pb.UpdateConfigRequest)), nil
+		}
+		if configHandler, ok := handler.(ConfigHandler); ok {
+			return configHandler.UpdateConfig(msg.(*pb.UpdateConfigRequest))
+		}
+		return noSettingsResponse(), nil
+	case MessageTypeSecrets:
+		SetSecrets(msg.(*pb.SecretsRequest).Values)
+		return &pb.SecretsResponse{Success: true}, nil
 	default:
-		return 0, nil, fmt.Errorf("unknown message type: %d", msgType)
+		return nil, fmt.Errorf("unknown message type: %d", msgType)
 	}
-
-	err = proto.Unmarshal(msgData, msg)
-	if err != nil {
-		return 0, nil, err
-	}
-
-	return msgType, msg, nil
 }
 
-func writeMessage(w io.Writer, msg proto.Message) error {
-	msgData, err := proto.Marshal(msg)
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.LittleEndian, uint32(len(msgData)))
-	if err != nil {
-		return err
+// ErrorResponse returns the response reporting err for req, a request of
+// msgType, so the host is answered even when a handler fails. It returns nil
+// for message types that are not requests.
+func ErrorResponse(msgType uint32, req proto.Message, err error) proto.Message {
+	switch msgType {
+	case MessageTypePluginInfo:
+		return &pb.PluginInfo{ErrorMessage: err.Error()}
+	case MessageTypeCommand:
+		response := &pb.CommandResponse{Success: false, ErrorMessage: err.Error()}
+		if req, ok := req.(*pb.CommandRequest); ok {
+			response.RequestId = req.RequestId
+		}
+		return response
+	case MessageTypeMenu:
+		return &pb.MenuResponse{ErrorMessage: err.Error()}
+	case MessageTypeSubscriptions:
+		return &pb.SubscriptionResponse{ErrorMessage: err.Error()}
+	case MessageTypeEvent:
+		return &pb.EventResponse{Success: false, ErrorMessage: err.Error()}
+	case MessageTypeConfig:
+		return &pb.ConfigResponse{ErrorMessage: err.Error()}
+	case MessageTypeUpdateConfig:
+		return &pb.UpdateConfigResponse{Success: false, ErrorMessage: err.Error()}
+	case MessageTypeSecrets:
+		return &pb.SecretsResponse{Success: false, ErrorMessage: err.Error()}
+	default:
+		return nil
 	}
-
-	_, err = w.Write(msgData)
-	return err
 }
 
-func ReadMessage(r io.Reader) (uint32, proto.Message, error) {
-	var msgType [1]byte
-	_, err := r.Read(msgType[:])
-	if err != nil {
-		return 0, nil, fmt.Errorf("failed to read message type: %w", err)
-	}
-	log.Debug("Read message type", "type", msgType[0])
-
-	var msgLen uint32
-	err = binary.Read(r, binary.LittleEndian, &msgLen)
+// GetPluginsDir returns the directory Gitspace loads plugins from. Each
+// plugin lives in a subdirectory named after it.
+func GetPluginsDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return 0, nil, fmt.Errorf("failed to read message length: %w", err)
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
 	}
-	log.Debug("Read message length", "length", msgLen)
+	return filepath.Join(homeDir, ".ssot", "gitspace", "plugins"), nil
+}
 
-	data := make([]byte, msgLen)
-	_, err = io.ReadFull(r, data)
+// GetLogsDir returns the directory holding every plugin's log directory.
+func GetLogsDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return 0, nil, fmt.Errorf("failed to read message data: %w", err)
-	}
-	log.Debug("Read message data", "dataLength", len(data), "rawData", fmt.Sprintf("%x", data))
-
-	var msg proto.Message
-	switch msgType[0] {
-	case 1:
-		msg = &pb.PluginInfoRequest{}
-	case 2:
-		msg = &pb.CommandRequest{}
-	case 3:
-		msg = &pb.MenuRequest{}
-	default:
-		return 0, nil, fmt.Errorf("unknown message type: %d", msgType[0])
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
 	}
+	return filepath.Join(homeDir, ".ssot", "gitspace", "logs"), nil
+}
 
-	err = proto.Unmarshal(data, msg)
+func GetPluginLogDir(pluginName string) (string, error) {
+	logsDir, err := GetLogsDir()
 	if err != nil {
-		return 0, nil, fmt.Errorf("failed to unmarshal message: %w", err)
+		return "", err
 	}
-
-	return uint32(msgType[0]), msg, nil
+	return filepath.Join(logsDir, pluginName), nil
 }
 
-func WriteMessage(w io.Writer, msg proto.Message) error {
-	data, err := proto.Marshal(msg)
+func GetPluginDataDir(pluginName string) (string, error) {
+	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
-	}
-	log.Debug("Marshaled message", "dataLength", len(data), "rawData", fmt.Sprintf("%x", data))
-
-	msgType := uint8(0)
-	switch msg.(type) {
-	case *pb.PluginInfo:
-		msgType = 1
-	case *pb.CommandResponse:
-		msgType = 2
-	case *pb.MenuResponse:
-		msgType = 3
-	default:
-		return fmt.Errorf("unknown message type: %T", msg)
-	}
-
-	log.Debug("Writing message type", "type", msgType)
-	if _, err := w.Write([]byte{msgType}); err != nil {
-		return fmt.Errorf("failed to write message type: %w", err)
-	}
-
-	log.Debug("Writing message length", "length", len(data))
-	if err := binary.Write(w, binary.LittleEndian, uint32(len(data))); err != nil {
-		return fmt.Errorf("failed to write message length: %w", err)
-	}
-
-	log.Debug("Writing message data", "dataLength", len(data))
-	if _, err := w.Write(data); err != nil {
-		return fmt.Errorf("failed to write message data: %w", err)
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
 	}
-
-	return nil
+	return filepath.Join(homeDir, ".ssot", "gitspace", "data", pluginName), nil
 }
 
-func GetPluginLogDir(pluginName string) (string, error) {
+func GetPluginConfigPath(pluginName string) (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get user home directory: %w", err)
 	}
-	return filepath.Join(homeDir, ".ssot", "gitspace", "logs", pluginName), nil
+	return filepath.Join(homeDir, ".ssot", "gitspace", "config", pluginName+".toml"), nil
 }
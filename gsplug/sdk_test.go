@@ -0,0 +1,118 @@
+package gsplug_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// reposPlugin answers every command with the repositories the host lists.
+type reposPlugin struct{}
+
+func (reposPlugin) GetPluginInfo(*pb.PluginInfoRequest) (*pb.PluginInfo, error) {
+	return &pb.PluginInfo{Name: "repos"}, nil
+}
+
+func (reposPlugin) ExecuteCommand(req *pb.CommandRequest) (*pb.CommandResponse, error) {
+	repositories, err := gsplug.ListRepositories()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CommandResponse{Success: true, Result: strings.Join(repositories, ",")}, nil
+}
+
+func (reposPlugin) GetMenu(*pb.MenuRequest) (*pb.MenuResponse, error) {
+	return &pb.MenuResponse{}, nil
+}
+
+// runPlugin runs RunPlugin on input with codec as the DefaultCodec and
+// returns the messages it wrote.
+func runPlugin(t *testing.T, codec gsplug.Codec, handler gsplug.PluginHandler, input []byte) []proto.Message {
+	t.Helper()
+
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin, stdout, defaultCodec := os.Stdin, os.Stdout, gsplug.DefaultCodec
+	os.Stdin, os.Stdout, gsplug.DefaultCodec = stdinR, stdoutW, codec
+	t.Cleanup(func() {
+		os.Stdin, os.Stdout, gsplug.DefaultCodec = stdin, stdout, defaultCodec
+		stdinR.Close()
+		stdoutR.Close()
+	})
+
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(stdoutR)
+		output <- data
+	}()
+	if _, err := stdinW.Write(input); err != nil {
+		t.Fatal(err)
+	}
+	stdinW.Close()
+
+	gsplug.RunPlugin(handler)
+	stdoutW.Close()
+
+	var msgs []proto.Message
+	r := bytes.NewReader(<-output)
+	for {
+		_, msg, err := codec.ReadPluginMessage(r)
+		if errors.Is(err, io.EOF) {
+			return msgs
+		}
+		if err != nil {
+			t.Fatalf("plugin wrote an unreadable message: %v", err)
+		}
+		msgs = append(msgs, msg)
+	}
+}
+
+func TestRunPluginHostCalls(t *testing.T) {
+	checked := gsplug.Framer{Framing: gsplug.FramingChecked}
+	hostCall := &pb.HostCallResponse{Success: true, Result: []byte(`["a","b"]`)}
+	request := encode(t, checked, command("list"), hostCall)
+	// A corrupt frame whose length covers the request and the host call
+	// response, so both are read from the bytes pushed back after it.
+	covering := corrupt(encode(t, checked, command("lost")), func(b []byte) {
+		length := binary.LittleEndian.Uint32(b[4:])
+		binary.LittleEndian.PutUint32(b[4:], length+uint32(len(request)))
+	})
+
+	tests := []struct {
+		name  string
+		codec gsplug.Codec
+		input []byte
+	}{
+		{"protobuf", gsplug.Framer{}, encode(t, gsplug.Framer{}, command("list"), hostCall)},
+		{"json", gsplug.JSONCodec{}, encode(t, gsplug.JSONCodec{}, command("list"), hostCall)},
+		{"after resync", checked, concat(covering, request)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs := runPlugin(t, tt.codec, reposPlugin{}, tt.input)
+			if len(msgs) != 2 {
+				t.Fatalf("plugin wrote %v", msgs)
+			}
+			if call, ok := msgs[0].(*pb.HostCallRequest); !ok || call.Method != gsplug.MethodListRepositories {
+				t.Fatalf("first message %v, want the host call", msgs[0])
+			}
+			if response, ok := msgs[1].(*pb.CommandResponse); !ok || response.Result != "a,b" {
+				t.Fatalf("second message %v, want the command response", msgs[1])
+			}
+		})
+	}
+}
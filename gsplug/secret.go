@@ -0,0 +1,252 @@
+package gsplug
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// Redacted replaces secret values in logs and formatted output.
+const Redacted = "[REDACTED]"
+
+// Secret wraps a sensitive value such as an API token. It formats as
+// Redacted everywhere (String, %v, %+v, %#v, JSON and slog); call Reveal to
+// get the actual value.
+type Secret struct {
+	name  string
+	value string
+}
+
+func NewSecret(name, value string) Secret {
+	return Secret{name: name, value: value}
+}
+
+func (s Secret) Name() string {
+	return s.name
+}
+
+// Reveal returns the secret value. Never log the result.
+func (s Secret) Reveal() string {
+	return s.value
+}
+
+func (s Secret) IsZero() bool {
+	return s.value == ""
+}
+
+func (s Secret) String() string {
+	return Redacted
+}
+
+func (s Secret) GoString() string {
+	return Redacted
+}
+
+func (s Secret) Format(f fmt.State, verb rune) {
+	fmt.Fprint(f, Redacted)
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + Redacted + `"`), nil
+}
+
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(Redacted), nil
+}
+
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(Redacted)
+}
+
+var secrets = struct {
+	sync.RWMutex
+	names  map[string]bool
+	values map[string]Secret
+}{
+	names:  make(map[string]bool),
+	values: make(map[string]Secret),
+}
+
+// RegisterSecretNames marks parameter names as secret so their values are
+// redacted from SDK logs even before the host delivers the secrets. Plugins
+// usually pass the names declared in their manifest.
+func RegisterSecretNames(names ...string) {
+	secrets.Lock()
+	defer secrets.Unlock()
+
+	for _, name := range names {
+		secrets.names[name] = true
+	}
+}
+
+// SetSecrets stores secrets delivered by the host. RunPlugin calls it when a
+// SecretsRequest arrives.
+func SetSecrets(values map[string]string) {
+	secrets.Lock()
+	defer secrets.Unlock()
+
+	for name, value := range values {
+		secrets.names[name] = true
+		secrets.values[name] = NewSecret(name, value)
+	}
+}
+
+// GetSecret returns the secret the host delivered under name.
+func GetSecret(name string) (Secret, bool) {
+	secrets.RLock()
+	defer secrets.RUnlock()
+
+	secret, ok := secrets.values[name]
+	return secret, ok
+}
+
+// IsSecretName reports whether name was declared or delivered as a secret.
+func IsSecretName(name string) bool {
+	secrets.RLock()
+	defer secrets.RUnlock()
+
+	return secrets.names[name]
+}
+
+// Redact replaces every known secret value in s with Redacted.
+func Redact(s string) string {
+	secrets.RLock()
+	defer secrets.RUnlock()
+
+	for _, secret := range secrets.values {
+		if secret.value != "" {
+			s = strings.ReplaceAll(s, secret.value, Redacted)
+		}
+	}
+	return s
+}
+
+// RedactKeyvals returns a copy of a logger's key/value pairs with the values
+// of secret keys replaced and known secret values scrubbed from strings and
+// errors.
+func RedactKeyvals(keyvals []interface{}) []interface{} {
+	redacted := make([]interface{}, len(keyvals))
+	for i, value := range keyvals {
+		if i%2 == 1 {
+			if key, ok := keyvals[i-1].(string); ok && IsSecretName(key) {
+				redacted[i] = Redacted
+				continue
+			}
+		}
+		switch v := value.(type) {
+		case string:
+			redacted[i] = Redact(v)
+		case error:
+			redacted[i] = Redact(v.Error())
+		default:
+			redacted[i] = value
+		}
+	}
+	return redacted
+}
+
+// RedactMessage formats a protocol message like %+v with secret parameters
+// and secret values hidden. Use it instead of fmt.Sprintf when logging
+// messages.
+func RedactMessage(msg proto.Message) string {
+	switch m := msg.(type) {
+	case *pb.SecretsRequest:
+		names := make([]string, 0, len(m.Values))
+		for name := range m.Values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Sprintf("secrets:%v", names)
+	case *pb.HostCallResponse:
+		// Host call results, such as the value returned by secrets.get, may
+		// be secrets the plugin has not registered yet.
+		clone := proto.Clone(m).(*pb.HostCallResponse)
+		if len(clone.Result) > 0 {
+			clone.Result = []byte(Redacted)
+		}
+		msg = clone
+	case *pb.CommandRequest:
+		clone := proto.Clone(m).(*pb.CommandRequest)
+		redactSecretNames(clone.Parameters)
+		msg = clone
+	case *pb.HostCallRequest:
+		clone := proto.Clone(m).(*pb.HostCallRequest)
+		redactSecretNames(clone.Parameters)
+		msg = clone
+	case *pb.ConfigResponse:
+		clone := proto.Clone(m).(*pb.ConfigResponse)
+		redactSecretNames(clone.Values)
+		msg = clone
+	case *pb.UpdateConfigRequest:
+		clone := proto.Clone(m).(*pb.UpdateConfigRequest)
+		redactSecretNames(clone.Values)
+		msg = clone
+	}
+	return Redact(fmt.Sprintf("%+v", msg))
+}
+
+// redactSecretNames replaces the values of keys named after a secret.
+func redactSecretNames(values map[string]string) {
+	for name := range values {
+		if IsSecretName(name) {
+			values[name] = Redacted
+		}
+	}
+}
+
+// dumpFrame renders frame data for debug logs, withholding it entirely when
+// it carries secrets.
+func dumpFrame(msg proto.Message, data []byte) string {
+	if containsSecrets(msg, data) {
+		return Redacted
+	}
+	return fmt.Sprintf("%x", data)
+}
+
+func containsSecrets(msg proto.Message, data []byte) bool {
+	switch m := msg.(type) {
+	case *pb.SecretsRequest, *pb.HostCallResponse:
+		return true
+	case *pb.CommandRequest:
+		if hasSecretName(m.Parameters) {
+			return true
+		}
+	case *pb.HostCallRequest:
+		if hasSecretName(m.Parameters) {
+			return true
+		}
+	case *pb.ConfigResponse:
+		if hasSecretName(m.Values) {
+			return true
+		}
+	case *pb.UpdateConfigRequest:
+		if hasSecretName(m.Values) {
+			return true
+		}
+	}
+
+	secrets.RLock()
+	defer secrets.RUnlock()
+
+	for _, secret := range secrets.values {
+		if secret.value != "" && bytes.Contains(data, []byte(secret.value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSecretName(values map[string]string) bool {
+	for name := range values {
+		if IsSecretName(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package gsplug_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSecretFormatting(t *testing.T) {
+	secret := gsplug.NewSecret("token", "s3cr3t-value")
+	wrapped := struct{ Token gsplug.Secret }{secret}
+
+	jsonBytes, err := json.Marshal(wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logged bytes.Buffer
+	slog.New(slog.NewTextHandler(&logged, nil)).Info("call", "token", secret)
+
+	outputs := map[string]string{
+		"String": secret.String(),
+		"%v":     fmt.Sprintf("%v", secret),
+		"%s":     fmt.Sprintf("%s", secret),
+		"%+v":    fmt.Sprintf("%+v", wrapped),
+		"%#v":    fmt.Sprintf("%#v", wrapped),
+		"JSON":   string(jsonBytes),
+		"slog":   logged.String(),
+	}
+	for name, output := range outputs {
+		if strings.Contains(output, "s3cr3t") || !strings.Contains(output, gsplug.Redacted) {
+			t.Errorf("%s: %s", name, output)
+		}
+	}
+	if secret.Reveal() != "s3cr3t-value" || secret.Name() != "token" {
+		t.Fatalf("Reveal = %q, Name = %q", secret.Reveal(), secret.Name())
+	}
+}
+
+func TestRedact(t *testing.T) {
+	gsplug.RegisterSecretNames("redact_test_key")
+	gsplug.SetSecrets(map[string]string{"redact_test_token": "tok-123456"})
+
+	if secret, ok := gsplug.GetSecret("redact_test_token"); !ok || secret.Reveal() != "tok-123456" {
+		t.Fatalf("GetSecret = %v, %v", secret.Reveal(), ok)
+	}
+	if !gsplug.IsSecretName("redact_test_key") || !gsplug.IsSecretName("redact_test_token") || gsplug.IsSecretName("redact_test_repo") {
+		t.Fatal("secret names were not recorded")
+	}
+
+	if got := gsplug.Redact("Authorization: Bearer tok-123456"); got != "Authorization: Bearer "+gsplug.Redacted {
+		t.Fatalf("Redact = %q", got)
+	}
+
+	keyvals := []interface{}{
+		"redact_test_key", "registered but never delivered",
+		"redact_test_repo", "sdk",
+		"url", "https://x/?t=tok-123456",
+		"error", errors.New("rejected tok-123456"),
+		"count", 3,
+	}
+	want := []interface{}{
+		"redact_test_key", gsplug.Redacted,
+		"redact_test_repo", "sdk",
+		"url", "https://x/?t=" + gsplug.Redacted,
+		"error", "rejected " + gsplug.Redacted,
+		"count", 3,
+	}
+	got := gsplug.RedactKeyvals(keyvals)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("RedactKeyvals = %v, want %v", got, want)
+	}
+	if keyvals[1] != "registered but never delivered" {
+		t.Fatal("RedactKeyvals modified its argument")
+	}
+}
+
+func TestRedactMessage(t *testing.T) {
+	gsplug.RegisterSecretNames("redact_message_key")
+	gsplug.SetSecrets(map[string]string{"redact_message_token": "tok-abcdef"})
+
+	tests := []struct {
+		name string
+		msg  proto.Message
+		keep string
+	}{
+		{"secrets request", &pb.SecretsRequest{Values: map[string]string{"b_token": "hidden-1", "a_token": "hidden-2"}}, "secrets:[a_token b_token]"},
+		{"command parameters", &pb.CommandRequest{Command: "sync", Parameters: map[string]string{"redact_message_key": "hidden-3", "repo": "sdk"}}, "sdk"},
+		{"host call parameters", &pb.HostCallRequest{Method: "repos.list", Parameters: map[string]string{"redact_message_key": "hidden-4"}}, "repos.list"},
+		{"host call result", &pb.HostCallResponse{Result: []byte("hidden-5")}, gsplug.Redacted},
+		{"config values", &pb.ConfigResponse{Values: map[string]string{"redact_message_key": "hidden-6"}}, gsplug.Redacted},
+		{"config update", &pb.UpdateConfigRequest{Values: map[string]string{"redact_message_key": "hidden-7"}}, gsplug.Redacted},
+		{"known value", &pb.CommandResponse{Result: "token is tok-abcdef"}, "token is"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := proto.Clone(tt.msg)
+			got := gsplug.RedactMessage(tt.msg)
+			if strings.Contains(got, "hidden") || strings.Contains(got, "tok-abcdef") || !strings.Contains(got, tt.keep) {
+				t.Fatalf("RedactMessage = %s", got)
+			}
+			if !proto.Equal(original, tt.msg) {
+				t.Fatal("RedactMessage modified the message")
+			}
+		})
+	}
+}
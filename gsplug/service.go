@@ -0,0 +1,68 @@
+package gsplug
+
+import (
+	"context"
+
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FromService adapts an implementation of the generated gRPC
+// PluginServiceServer to a PluginHandler, so a plugin written against the
+// service definition runs over stdio with RunPlugin. Methods the server
+// leaves unimplemented behave as if the plugin did not support them.
+func FromService(server pb.PluginServiceServer) PluginHandler {
+	return &serviceHandler{server: server}
+}
+
+type serviceHandler struct {
+	server pb.PluginServiceServer
+}
+
+func (h *serviceHandler) GetPluginInfo(req *pb.PluginInfoRequest) (*pb.PluginInfo, error) {
+	return h.server.GetPluginInfo(context.Background(), req)
+}
+
+func (h *serviceHandler) ExecuteCommand(req *pb.CommandRequest) (*pb.CommandResponse, error) {
+	return h.server.ExecuteCommand(context.Background(), req)
+}
+
+func (h *serviceHandler) GetMenu(req *pb.MenuRequest) (*pb.MenuResponse, error) {
+	return h.server.GetMenu(context.Background(), req)
+}
+
+func (h *serviceHandler) GetSubscriptions(req *pb.SubscriptionRequest) (*pb.SubscriptionResponse, error) {
+	response, err := h.server.GetSubscriptions(context.Background(), req)
+	if status.Code(err) == codes.Unimplemented {
+		return &pb.SubscriptionResponse{}, nil
+	}
+	return response, err
+}
+
+func (h *serviceHandler) HandleEvent(event *pb.Event) (*pb.EventResponse, error) {
+	response, err := h.server.HandleEvent(context.Background(), event)
+	if status.Code(err) == codes.Unimplemented {
+		return &pb.EventResponse{
+			Success:      false,
+			ErrorMessage: "plugin does not handle events",
+		}, nil
+	}
+	return response, err
+}
+
+func (h *serviceHandler) GetConfig(req *pb.ConfigRequest) (*pb.ConfigResponse, error) {
+	response, err := h.server.GetConfig(context.Background(), req)
+	if status.Code(err) == codes.Unimplemented {
+		return &pb.ConfigResponse{}, nil
+	}
+	return response, err
+}
+
+func (h *serviceHandler) UpdateConfig(req *pb.UpdateConfigRequest) (*pb.UpdateConfigResponse, error) {
+	response, err := h.server.UpdateConfig(context.Background(), req)
+	if status.Code(err) == codes.Unimplemented {
+		return noSettingsResponse(), nil
+	}
+	return response, err
+}
@@ -0,0 +1,67 @@
+package gsplug_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// configService implements only the config RPCs.
+type configService struct {
+	pb.UnimplementedPluginServiceServer
+	updated map[string]string
+}
+
+func (s *configService) GetConfig(context.Context, *pb.ConfigRequest) (*pb.ConfigResponse, error) {
+	return &pb.ConfigResponse{Values: map[string]string{"greeting": "hello"}}, nil
+}
+
+func (s *configService) UpdateConfig(_ context.Context, req *pb.UpdateConfigRequest) (*pb.UpdateConfigResponse, error) {
+	s.updated = req.Values
+	return &pb.UpdateConfigResponse{Success: true}, nil
+}
+
+func TestFromServiceConfig(t *testing.T) {
+	service := &configService{}
+	handler := gsplug.FromService(service)
+
+	response, err := gsplug.HandleMessage(handler, gsplug.MessageTypeConfig, &pb.ConfigRequest{})
+	if err != nil || response.(*pb.ConfigResponse).Values["greeting"] != "hello" {
+		t.Fatalf("config request answered %v, %v", response, err)
+	}
+	values := map[string]string{"greeting": "hi"}
+	response, err = gsplug.HandleMessage(handler, gsplug.MessageTypeUpdateConfig, &pb.UpdateConfigRequest{Values: values})
+	if err != nil || !response.(*pb.UpdateConfigResponse).Success {
+		t.Fatalf("config update answered %v, %v", response, err)
+	}
+	if service.updated["greeting"] != "hi" {
+		t.Fatalf("service received %v", service.updated)
+	}
+}
+
+func TestFromServiceUnimplemented(t *testing.T) {
+	handler := gsplug.FromService(pb.UnimplementedPluginServiceServer{})
+
+	tests := []struct {
+		name    string
+		msgType uint32
+		request proto.Message
+		want    proto.Message
+	}{
+		{"subscriptions", gsplug.MessageTypeSubscriptions, &pb.SubscriptionRequest{}, &pb.SubscriptionResponse{}},
+		{"event", gsplug.MessageTypeEvent, &pb.Event{}, &pb.EventResponse{ErrorMessage: "plugin does not handle events"}},
+		{"config", gsplug.MessageTypeConfig, &pb.ConfigRequest{}, &pb.ConfigResponse{}},
+		{"update config", gsplug.MessageTypeUpdateConfig, &pb.UpdateConfigRequest{}, &pb.UpdateConfigResponse{ErrorMessage: "plugin has no configurable settings"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := gsplug.HandleMessage(handler, tt.msgType, tt.request)
+			if err != nil || !proto.Equal(response, tt.want) {
+				t.Fatalf("got %v, %v, want %v", response, err, tt.want)
+			}
+		})
+	}
+}
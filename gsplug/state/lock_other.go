@@ -0,0 +1,94 @@
+//go:build !unix
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// lockTimeout is how long lockFile waits for a live holder before giving
+	// up.
+	lockTimeout = 30 * time.Second
+	// lockStaleAge is the age after which a lock file is taken over even if
+	// its owner still seems to run; no store operation holds it that long.
+	lockStaleAge = 2 * time.Minute
+)
+
+// lockFile emulates an exclusive lock by creating path with O_EXCL and
+// writing the owner's PID into it, retrying until the holder removes it.
+// A lock whose owner has exited or that is older than lockStaleAge is
+// removed, so a crashed process does not block the store forever. Readers
+// and writers are not distinguished.
+func lockFile(path string, write bool) (func(), error) {
+	owner := []byte(strconv.Itoa(os.Getpid()))
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, err = file.Write(owner)
+			file.Close()
+			if err != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("failed to write lock owner: %w", err)
+			}
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		holder, stale := staleLock(path)
+		if stale {
+			// Remove the file only if it still names the same owner, so a
+			// lock another waiter just took over survives.
+			if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, holder) {
+				os.Remove(path)
+			}
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s held by process %s", path, holder)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// staleLock reads the owner recorded in the lock file at path and reports
+// whether the lock can be taken over.
+func staleLock(path string) ([]byte, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	holder, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	if time.Since(info.ModTime()) > lockStaleAge {
+		return holder, true
+	}
+	pid, err := strconv.Atoi(string(holder))
+	if err != nil {
+		// The owner may not have written its PID yet.
+		return holder, false
+	}
+	return holder, !processAlive(pid)
+}
+
+// processAlive reports whether a process with pid exists. Where
+// os.FindProcess cannot tell (it only checks on Windows), the process is
+// assumed alive and the lock only goes stale by age.
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
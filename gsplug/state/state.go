@@ -0,0 +1,241 @@
+// Package state gives each plugin a small persistent key-value store under
+// ~/.ssot/gitspace/data/<plugin>.
+//
+// Values are stored as JSON in a single file. Every operation takes a file
+// lock and writes through a temporary file that is renamed into place, so
+// several plugin processes can share a store without corrupting it.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+)
+
+const (
+	dataFileName = "state.json"
+	lockFileName = "state.lock"
+)
+
+type entry struct {
+	Value     json.RawMessage `json:"value"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+}
+
+func (e entry) expired(now time.Time) bool {
+	return e.ExpiresAt != nil && !now.Before(*e.ExpiresAt)
+}
+
+type Store struct {
+	dir      string
+	dataPath string
+	lockPath string
+	mu       sync.Mutex
+}
+
+// Open returns the store for pluginName, creating its data directory if
+// needed.
+func Open(pluginName string) (*Store, error) {
+	dir, err := gsplug.GetPluginDataDir(pluginName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plugin data directory: %w", err)
+	}
+	return OpenDir(dir)
+}
+
+// OpenDir returns a store backed by dir.
+func OpenDir(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	return &Store{
+		dir:      dir,
+		dataPath: filepath.Join(dir, dataFileName),
+		lockPath: filepath.Join(dir, lockFileName),
+	}, nil
+}
+
+// Dir returns the directory holding the store's files.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Get decodes the value stored under key into value. It reports false if the
+// key does not exist or has expired.
+func (s *Store) Get(key string, value any) (bool, error) {
+	var found bool
+	err := s.withLock(false, func(entries map[string]entry) (bool, error) {
+		e, ok := entries[key]
+		if !ok || e.expired(time.Now()) {
+			return false, nil
+		}
+		if err := json.Unmarshal(e.Value, value); err != nil {
+			return false, fmt.Errorf("failed to decode value for %q: %w", key, err)
+		}
+		found = true
+		return false, nil
+	})
+	return found, err
+}
+
+// Put stores value under key without an expiry.
+func (s *Store) Put(key string, value any) error {
+	return s.put(key, value, 0)
+}
+
+// PutWithTTL stores value under key; it is treated as absent once ttl has
+// elapsed.
+func (s *Store) PutWithTTL(key string, value any, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("ttl must be positive, got %s", ttl)
+	}
+	return s.put(key, value, ttl)
+}
+
+func (s *Store) put(key string, value any, ttl time.Duration) error {
+	if key == "" {
+		return errors.New("key must not be empty")
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode value for %q: %w", key, err)
+	}
+
+	e := entry{Value: data}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		e.ExpiresAt = &expiresAt
+	}
+
+	return s.withLock(true, func(entries map[string]entry) (bool, error) {
+		entries[key] = e
+		return true, nil
+	})
+}
+
+// Delete removes key. Deleting a missing key is not an error.
+func (s *Store) Delete(key string) error {
+	return s.withLock(true, func(entries map[string]entry) (bool, error) {
+		if _, ok := entries[key]; !ok {
+			return false, nil
+		}
+		delete(entries, key)
+		return true, nil
+	})
+}
+
+// List returns the live keys starting with prefix in sorted order.
+func (s *Store) List(prefix string) ([]string, error) {
+	var keys []string
+	err := s.withLock(false, func(entries map[string]entry) (bool, error) {
+		now := time.Now()
+		for key, e := range entries {
+			if strings.HasPrefix(key, prefix) && !e.expired(now) {
+				keys = append(keys, key)
+			}
+		}
+		return false, nil
+	})
+	sort.Strings(keys)
+	return keys, err
+}
+
+// Get is a typed wrapper around Store.Get.
+func Get[T any](s *Store, key string) (T, bool, error) {
+	var value T
+	found, err := s.Get(key, &value)
+	return value, found, err
+}
+
+// withLock loads the store under the file lock and calls fn with its
+// entries. When write is set and fn reports a change, the entries are saved
+// before the lock is released.
+func (s *Store) withLock(write bool, fn func(map[string]entry) (bool, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.lockPath, write)
+	if err != nil {
+		return fmt.Errorf("failed to lock state store: %w", err)
+	}
+	defer unlock()
+
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	changed, err := fn(entries)
+	if err != nil || !write || !changed {
+		return err
+	}
+
+	now := time.Now()
+	for key, e := range entries {
+		if e.expired(now) {
+			delete(entries, key)
+		}
+	}
+	return s.save(entries)
+}
+
+func (s *Store) load() (map[string]entry, error) {
+	entries := make(map[string]entry)
+
+	data, err := os.ReadFile(s.dataPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	if len(data) == 0 {
+		return entries, nil
+	}
+
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode state file: %w", err)
+	}
+	return entries, nil
+}
+
+// save writes entries to a temporary file and renames it over the data file
+// so readers never observe a partial write.
+func (s *Store) save(entries map[string]entry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state file: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, dataFileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary state file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temporary state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary state file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.dataPath); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package state_test
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug/state"
+)
+
+func openStore(t *testing.T, dir string) *state.Store {
+	t.Helper()
+	store, err := state.OpenDir(dir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	return store
+}
+
+func TestPutGet(t *testing.T) {
+	dir := t.TempDir()
+	store := openStore(t, dir)
+
+	type repo struct {
+		Name  string
+		Stars int
+	}
+	if err := store.Put("repo", repo{Name: "sdk", Stars: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second handle on the same directory sees the value.
+	got, found, err := state.Get[repo](openStore(t, dir), "repo")
+	if err != nil || !found || got != (repo{Name: "sdk", Stars: 3}) {
+		t.Fatalf("Get = %+v, %v, %v", got, found, err)
+	}
+
+	if _, found, err := state.Get[repo](store, "missing"); found || err != nil {
+		t.Fatalf("missing key: found %v, err %v", found, err)
+	}
+	if _, _, err := state.Get[int](store, "repo"); err == nil {
+		t.Fatal("decoding into the wrong type succeeded")
+	}
+}
+
+func TestDeleteAndList(t *testing.T) {
+	store := openStore(t, t.TempDir())
+	for _, key := range []string{"repo/b", "repo/a", "other"} {
+		if err := store.Put(key, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Delete("repo/b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete("never-stored"); err != nil {
+		t.Fatalf("deleting a missing key: %v", err)
+	}
+
+	keys, err := store.List("repo/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []string{"repo/a"}) {
+		t.Fatalf("List = %v", keys)
+	}
+	if keys, _ := store.List(""); !reflect.DeepEqual(keys, []string{"other", "repo/a"}) {
+		t.Fatalf("List(\"\") = %v", keys)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	store := openStore(t, t.TempDir())
+	if err := store.PutWithTTL("token", "abc", 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if _, found, _ := state.Get[string](store, "token"); !found {
+		t.Fatal("value expired early")
+	}
+
+	time.Sleep(80 * time.Millisecond)
+	if _, found, _ := state.Get[string](store, "token"); found {
+		t.Fatal("expired value was returned")
+	}
+	if keys, _ := store.List(""); len(keys) != 0 {
+		t.Fatalf("expired key listed: %v", keys)
+	}
+
+	if err := store.PutWithTTL("token", "abc", 0); err == nil {
+		t.Fatal("a zero TTL was accepted")
+	}
+	if err := store.Put("", 1); err == nil {
+		t.Fatal("an empty key was accepted")
+	}
+}
+
+func TestConcurrentStores(t *testing.T) {
+	dir := t.TempDir()
+	stores := []*state.Store{openStore(t, dir), openStore(t, dir)}
+
+	const writes = 20
+	var wg sync.WaitGroup
+	for i, store := range stores {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < writes; n++ {
+				if err := store.Put(fmt.Sprintf("%d/%02d", i, n), n); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	keys, err := stores[0].List("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != len(stores)*writes {
+		t.Fatalf("%d keys survived concurrent writes, want %d", len(keys), len(stores)*writes)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if name := file.Name(); name != "state.json" && name != "state.lock" {
+			t.Errorf("unexpected file %s left in the store", name)
+		}
+	}
+}
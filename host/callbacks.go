@@ -0,0 +1,42 @@
+package host
+
+import (
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+// CallbackFunc implements a host callback. The returned bytes are passed to
+// the plugin unchanged; gsplug's helpers expect JSON except for secrets.
+type CallbackFunc func(parameters map[string]string) ([]byte, error)
+
+// handleCallback runs the callback for req if the plugin's grant includes
+// the method's capability.
+func (c *Client) handleCallback(req *pb.HostCallRequest) *pb.HostCallResponse {
+	capability := gsplug.CallbackCapability(req.Method)
+	if c.opts.Grant == nil || !c.opts.Grant.Permissions.HasCallback(capability) {
+		return &pb.HostCallResponse{
+			Success:      false,
+			ErrorMessage: gsplug.ErrPermissionDenied.Error(),
+		}
+	}
+
+	callback, ok := c.opts.Callbacks[req.Method]
+	if !ok {
+		return &pb.HostCallResponse{
+			Success:      false,
+			ErrorMessage: "unknown host callback: " + req.Method,
+		}
+	}
+
+	result, err := callback(req.Parameters)
+	if err != nil {
+		return &pb.HostCallResponse{
+			Success:      false,
+			ErrorMessage: err.Error(),
+		}
+	}
+	return &pb.HostCallResponse{
+		Success: true,
+		Result:  result,
+	}
+}
@@ -0,0 +1,65 @@
+package host_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	"github.com/ssotops/gitspace-plugin-sdk/host"
+)
+
+func TestCallbackPermissions(t *testing.T) {
+	listRepositories := func(map[string]string) ([]byte, error) {
+		return []byte(`["ssotops/gitspace","ssotops/sdk"]`), nil
+	}
+	getSecret := func(parameters map[string]string) ([]byte, error) {
+		if parameters["name"] != "token" {
+			return nil, errors.New("no secret named " + parameters["name"])
+		}
+		return []byte("tok-123"), nil
+	}
+	grant := func(capabilities ...gsplug.Capability) *host.Grant {
+		return &host.Grant{Plugin: "test-plugin", Permissions: gsplug.Permissions{Callbacks: capabilities}}
+	}
+	callbacks := map[string]host.CallbackFunc{
+		gsplug.MethodListRepositories: listRepositories,
+		gsplug.MethodGetSecret:        getSecret,
+	}
+
+	tests := []struct {
+		name      string
+		grant     *host.Grant
+		callbacks map[string]host.CallbackFunc
+		command   string
+		params    map[string]string
+		result    string
+		error     string
+	}{
+		{name: "granted", grant: grant(gsplug.CapabilityRepositories), callbacks: callbacks, command: "repos", result: "ssotops/gitspace,ssotops/sdk"},
+		{name: "no grant", callbacks: callbacks, command: "repos", error: "repositories.list: permission denied"},
+		{name: "other capability", grant: grant(gsplug.CapabilitySecrets), callbacks: callbacks, command: "repos", error: "permission denied"},
+		{name: "not implemented", grant: grant(gsplug.CapabilityRepositories), command: "repos", error: "unknown host callback: repositories.list"},
+		{name: "secret", grant: grant(gsplug.CapabilitySecrets), callbacks: callbacks, command: "secret", params: map[string]string{"name": "token"}, result: "tok-123"},
+		{name: "callback error", grant: grant(gsplug.CapabilitySecrets), callbacks: callbacks, command: "secret", params: map[string]string{"name": "other"}, error: "no secret named other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := startPlugin(t, "ok", host.Options{Grant: tt.grant, Callbacks: tt.callbacks})
+
+			response, err := client.ExecuteCommand(tt.command, tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.error != "" {
+				if response.Success || !strings.Contains(response.ErrorMessage, tt.error) {
+					t.Fatalf("got %+v, want failure %q", response, tt.error)
+				}
+				return
+			}
+			if !response.Success || response.Result != tt.result {
+				t.Fatalf("got %+v, want result %q", response, tt.result)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+//go:build linux
+
+package host
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// cgroup is a cgroup v2 created for one plugin process.
+type cgroup struct {
+	path string
+	dir  *os.File
+}
+
+// newCgroup creates a child of the host's own cgroup with memory.max set.
+// It fails unless cgroup v2 is mounted and delegated to the current user.
+func newCgroup(name string, memoryBytes uint64) (*cgroup, error) {
+	var fs unix.Statfs_t
+	if err := unix.Statfs(cgroupRoot, &fs); err != nil {
+		return nil, err
+	}
+	if fs.Type != unix.CGROUP2_SUPER_MAGIC {
+		return nil, fmt.Errorf("%s is not a cgroup v2 mount", cgroupRoot)
+	}
+
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return nil, err
+	}
+
+	var parent string
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(line, "0::"); ok {
+			parent = rest
+			break
+		}
+	}
+	if parent == "" {
+		return nil, fmt.Errorf("cgroup v2 is not in use")
+	}
+
+	path := filepath.Join(cgroupRoot, parent, fmt.Sprintf("gitspace-plugin-%s-%d", name, time.Now().UnixNano()))
+	if err := os.Mkdir(path, 0755); err != nil {
+		return nil, err
+	}
+
+	memoryMax := strconv.FormatUint(memoryBytes, 10)
+	if err := os.WriteFile(filepath.Join(path, "memory.max"), []byte(memoryMax), 0644); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	dir, err := os.Open(path)
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return &cgroup{path: path, dir: dir}, nil
+}
+
+// attach places the process started by cmd into the cgroup.
+func (g *cgroup) attach(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(g.dir.Fd())
+}
+
+// oomKilled reports whether the kernel killed a process in the cgroup for
+// exceeding memory.max.
+func (g *cgroup) oomKilled() bool {
+	file, err := os.Open(filepath.Join(g.path, "memory.events"))
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if count, ok := strings.CutPrefix(scanner.Text(), "oom_kill "); ok {
+			return count != "0"
+		}
+	}
+	return false
+}
+
+func (g *cgroup) remove() {
+	g.dir.Close()
+	os.Remove(g.path)
+}
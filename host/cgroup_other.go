@@ -0,0 +1,22 @@
+//go:build !linux
+
+package host
+
+import (
+	"errors"
+	"os/exec"
+)
+
+type cgroup struct{}
+
+func newCgroup(name string, memoryBytes uint64) (*cgroup, error) {
+	return nil, errors.New("cgroups are only supported on linux")
+}
+
+func (g *cgroup) attach(cmd *exec.Cmd) {}
+
+func (g *cgroup) oomKilled() bool {
+	return false
+}
+
+func (g *cgroup) remove() {}
@@ -0,0 +1,661 @@
+// Package host implements the Gitspace side of the plugin protocol: it
+// launches a plugin binary, exchanges framed messages over its stdio and
+// answers the host callbacks the plugin is allowed to make.
+package host
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// Options configures how a plugin is launched and which callbacks it may
+// use.
+type Options struct {
+	// Dir is the plugin's working directory. Defaults to the host's.
+	Dir string
+	// Env is the plugin's environment. Defaults to the host's.
+	Env []string
+	// Stderr receives the plugin's stderr. Defaults to os.Stderr.
+	Stderr io.Writer
+	// Grant holds the permissions the user approved for the plugin. Host
+	// callbacks are denied when it is nil or lacks the capability.
+	Grant *Grant
+	// Callbacks maps host callback methods, e.g. gsplug.MethodListRepositories,
+	// to their implementation.
+	Callbacks map[string]CallbackFunc
+	// Sandbox, when set, launches the plugin with reduced privileges.
+	Sandbox *SandboxPolicy
+	// Limits bounds the plugin's resource usage.
+	Limits Limits
+	// Framing selects the wire format. Anything but gsplug.FramingLegacy is
+	// announced to the plugin through gsplug.FramingEnv, so it requires a
+	// plugin built with a recent SDK.
+	Framing gsplug.Framing
+	// Compression gzips frames above gsplug.DefaultCompressionThreshold in
+	// both directions. It is announced to the plugin through
+	// gsplug.CompressionEnv and, like Framing, requires a recent SDK.
+	Compression gsplug.Compression
+	// Codec selects the message encoding. gsplug.CodecJSON is announced
+	// through gsplug.CodecEnv and ignores Framing and Compression.
+	Codec gsplug.CodecName
+	// Logs receives the plugin's log records. Setting it announces
+	// gsplug.LogsEnv, so SDK loggers forward their records instead of
+	// writing them to stderr. Records arrive while a request is in flight;
+	// those logged between requests are delivered with the next one.
+	Logs slog.Handler
+	// Logger, when set, receives the client's own records about the
+	// commands it runs, tagged with the request ID sent to the plugin so
+	// they can be matched with the plugin's records for the same command.
+	Logger *slog.Logger
+}
+
+// ErrPluginStopped is returned by requests to a plugin that was killed for
+// exceeding a limit and not restarted.
+var ErrPluginStopped = errors.New("plugin was stopped")
+
+// Client talks to a running plugin process. Requests are serialized; the
+// protocol carries one exchange at a time.
+type Client struct {
+	binary string
+	opts   Options
+	codec  gsplug.Codec
+	mu     sync.Mutex
+	// process is the running plugin; Reload swaps it for a new one.
+	process
+	// delivered is what the plugin was sent or answered, which each new
+	// process is brought up to date with. It is guarded by mu.
+	delivered delivered
+
+	pendingMu sync.Mutex
+	pending   int
+}
+
+// delivered holds the secrets and config updates a plugin accepted and the
+// events it last subscribed to.
+type delivered struct {
+	secrets       map[string]string
+	config        map[string]string
+	subscriptions []gsplug.EventType
+	subscribed    bool
+}
+
+// process is the state of one running plugin process.
+type process struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	stdout   io.Reader
+	cgroup   *cgroup
+	waitErr  chan error
+	stopped  bool
+	warnings []string
+}
+
+// Start launches the plugin binary and returns a client connected to it.
+func Start(binary string, opts Options) (*Client, error) {
+	c := &Client{
+		binary: binary,
+		opts:   opts,
+		codec: gsplug.Framer{
+			Framing:      opts.Framing,
+			MaxFrameSize: opts.Limits.MaxFrameSize,
+			Compression:  opts.Compression,
+		},
+	}
+	if opts.Codec == gsplug.CodecJSON {
+		c.codec = gsplug.JSONCodec{MaxLineSize: opts.Limits.MaxFrameSize}
+	}
+	if err := c.start(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// start launches the plugin, makes it the client's process and sends it the
+// state delivered to the previous one. Callers must hold c.mu or own c
+// exclusively.
+func (c *Client) start() error {
+	p, err := c.spawn()
+	if err != nil {
+		return err
+	}
+	c.process = p
+	if err := c.restore(); err != nil {
+		c.kill()
+		return err
+	}
+	return nil
+}
+
+// spawn launches a new plugin process without touching the current one.
+func (c *Client) spawn() (process, error) {
+	var warnings []string
+	policy := c.opts.Sandbox
+	namespaces := false
+	if policy != nil {
+		if err := policy.prepare(); err != nil {
+			return process{}, err
+		}
+		namespaces = policy.usesNamespaces()
+		if namespaces && runtime.GOOS != "linux" {
+			warnings = append(warnings, "namespaces are only supported on linux")
+			namespaces = false
+		}
+	}
+
+	p, err := c.launch(namespaces)
+	if err != nil && namespaces && namespaceUnavailable(err) {
+		warnings = append(warnings,
+			fmt.Sprintf("namespaces unavailable, started without them: %v", err))
+		p, err = c.launch(false)
+	}
+	if err != nil {
+		return process{}, err
+	}
+	p.warnings = append(warnings, p.warnings...)
+	return p, nil
+}
+
+// protocolEnv announces the non-default protocol options to the plugin.
+func (c *Client) protocolEnv() []string {
+	var env []string
+	if c.opts.Framing != "" && c.opts.Framing != gsplug.FramingLegacy {
+		env = append(env, gsplug.FramingEnv+"="+string(c.opts.Framing))
+	}
+	if c.opts.Compression != gsplug.CompressionNone {
+		env = append(env, gsplug.CompressionEnv+"="+string(c.opts.Compression))
+	}
+	if c.opts.Codec == gsplug.CodecJSON {
+		env = append(env, gsplug.CodecEnv+"="+string(c.opts.Codec))
+	}
+	if c.opts.Logs != nil {
+		env = append(env, gsplug.LogsEnv+"="+gsplug.LogsForward)
+	}
+	return env
+}
+
+func (c *Client) launch(namespaces bool) (process, error) {
+	var p process
+	cmd := exec.Command(c.binary)
+	cmd.Dir = c.opts.Dir
+	cmd.Env = c.opts.Env
+	cmd.Stderr = c.opts.Stderr
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+
+	policy := c.opts.Sandbox
+	if policy != nil {
+		if policy.WorkDir != "" {
+			cmd.Dir = policy.WorkDir
+		}
+		base := cmd.Env
+		if base == nil {
+			base = os.Environ()
+		}
+		cmd.Env = policy.environ(base)
+		applySandbox(cmd, policy, namespaces)
+	}
+	if env := c.protocolEnv(); len(env) > 0 {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, env...)
+	}
+
+	var group *cgroup
+	if memory := c.opts.Limits.MemoryBytes; memory > 0 {
+		var err error
+		group, err = newCgroup(c.Name(), memory)
+		if err != nil {
+			p.warnings = append(p.warnings,
+				fmt.Sprintf("cgroup unavailable, limiting memory with rlimit: %v", err))
+		} else {
+			group.attach(cmd)
+		}
+	}
+
+	var memoryRlimit uint64
+	if group == nil {
+		memoryRlimit = c.opts.Limits.MemoryBytes
+	}
+	warnings, err := confine(cmd, policy, memoryRlimit)
+	if err != nil {
+		if group != nil {
+			group.remove()
+		}
+		return process{}, err
+	}
+	p.warnings = append(p.warnings, warnings...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return process{}, fmt.Errorf("failed to create plugin stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return process{}, fmt.Errorf("failed to create plugin stdout: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		if group != nil {
+			group.remove()
+		}
+		return process{}, fmt.Errorf("failed to start plugin %s: %w", c.binary, err)
+	}
+
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- cmd.Wait()
+	}()
+
+	p.cmd = cmd
+	p.stdin = stdin
+	p.stdout = gsplug.NewFrameReader(stdout)
+	p.cgroup = group
+	p.waitErr = waitErr
+	return p, nil
+}
+
+// Warnings describes sandbox restrictions and limits that could not be
+// applied when the plugin was started.
+func (c *Client) Warnings() []string {
+	return c.warnings
+}
+
+// Binary returns the path of the plugin executable.
+func (c *Client) Binary() string {
+	return c.binary
+}
+
+// Name returns the plugin's name as used in errors, derived from its binary.
+func (c *Client) Name() string {
+	return filepath.Base(c.binary)
+}
+
+// Close closes the plugin's stdin, which makes a well-behaved plugin exit,
+// and waits for the process. Log records the plugin sends while exiting are
+// passed to Options.Logs.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopped {
+		return nil
+	}
+	c.stopped = true
+
+	c.stdin.Close()
+	c.drain(c.process)
+	err := <-c.waitErr
+	if c.cgroup != nil {
+		c.cgroup.remove()
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("plugin exited: %w", err)
+	}
+	return err
+}
+
+// Restart kills the plugin process and starts it again.
+func (c *Client) Restart() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.kill()
+	return c.start()
+}
+
+// kill stops the plugin process and releases its resources. Callers must
+// hold c.mu.
+func (c *Client) kill() {
+	if c.stopped {
+		return
+	}
+	c.stopped = true
+
+	c.stdin.Close()
+	c.cmd.Process.Kill()
+	<-c.waitErr
+	if c.cgroup != nil {
+		c.cgroup.remove()
+	}
+}
+
+// acquire counts a request against MaxConcurrentRequests.
+func (c *Client) acquire() error {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	if max := c.opts.Limits.MaxConcurrentRequests; max > 0 && c.pending >= max {
+		return &LimitError{
+			Plugin: c.Name(),
+			Kind:   LimitConcurrency,
+			Detail: fmt.Sprintf("%d requests already pending", c.pending),
+		}
+	}
+	c.pending++
+	return nil
+}
+
+func (c *Client) release() {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	c.pending--
+}
+
+type exchangeResult struct {
+	msg proto.Message
+	err error
+}
+
+// roundTrip sends req and returns the plugin's response, enforcing the
+// client's limits.
+func (c *Client) roundTrip(req proto.Message) (proto.Message, error) {
+	return c.roundTripStream(req, nil)
+}
+
+// roundTripStream is like roundTrip and additionally writes chunks the
+// plugin streams for req to sink.
+func (c *Client) roundTripStream(req proto.Message, sink *chunkSink) (proto.Message, error) {
+	if err := c.acquire(); err != nil {
+		return nil, err
+	}
+	defer c.release()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopped {
+		return nil, ErrPluginStopped
+	}
+
+	timeout := c.requestTimeout()
+	if timeout < 0 {
+		msg, err := c.exchange(req, sink)
+		return msg, c.checkExchange(err)
+	}
+
+	done := make(chan exchangeResult, 1)
+	go func() {
+		msg, err := c.exchange(req, sink)
+		done <- exchangeResult{msg, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case result := <-done:
+		return result.msg, c.checkExchange(result.err)
+	case <-timer.C:
+		c.cmd.Process.Kill()
+		<-done
+		return nil, c.violation(LimitTimeout, fmt.Sprintf("no response within %s", timeout), nil)
+	}
+}
+
+// requestTimeout returns how long a request may take; negative means no
+// limit.
+func (c *Client) requestTimeout() time.Duration {
+	if timeout := c.opts.Limits.RequestTimeout; timeout != 0 {
+		return timeout
+	}
+	return DefaultRequestTimeout
+}
+
+// checkExchange turns exchange errors caused by a limit into a LimitError.
+func (c *Client) checkExchange(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gsplug.ErrFrameTooLarge) {
+		return c.violation(LimitFrameSize, err.Error(), err)
+	}
+	if c.cgroup != nil && c.cgroup.oomKilled() {
+		return c.violation(LimitMemory,
+			fmt.Sprintf("killed after exceeding %d bytes", c.opts.Limits.MemoryBytes), err)
+	}
+	return err
+}
+
+// violation kills the plugin, restarts it if configured and returns the
+// LimitError describing what happened. Callers must hold c.mu.
+func (c *Client) violation(kind LimitKind, detail string, err error) error {
+	limitErr := &LimitError{
+		Plugin: c.Name(),
+		Kind:   kind,
+		Detail: detail,
+		Err:    err,
+	}
+
+	c.kill()
+	if c.opts.Limits.RestartOnViolation {
+		if restartErr := c.start(); restartErr != nil {
+			limitErr.Detail += fmt.Sprintf("; restart failed: %v", restartErr)
+		} else {
+			limitErr.Restarted = true
+		}
+	}
+	return limitErr
+}
+
+// exchange sends req and reads the plugin's response, answering any host
+// calls and acknowledging any chunks the plugin sends in between.
+func (c *Client) exchange(req proto.Message, sink *chunkSink) (proto.Message, error) {
+	if err := c.codec.WriteMessage(c.stdin, req); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	for {
+		msgType, msg, err := c.codec.ReadPluginMessage(c.stdout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+
+		if msgType == gsplug.MessageTypeHostCall {
+			response := c.handleCallback(msg.(*pb.HostCallRequest))
+			if err := c.codec.WriteMessage(c.stdin, response); err != nil {
+				return nil, fmt.Errorf("failed to send host call response: %w", err)
+			}
+			continue
+		}
+
+		if msgType == gsplug.MessageTypeLog {
+			c.handleLog(msg.(*pb.LogRecord))
+			continue
+		}
+
+		if msgType == gsplug.MessageTypeChunk {
+			chunk := msg.(*pb.Chunk)
+			sink.write(chunk)
+			ack := &pb.ChunkAck{RequestId: chunk.RequestId, Sequence: chunk.Sequence}
+			if err := c.codec.WriteMessage(c.stdin, ack); err != nil {
+				return nil, fmt.Errorf("failed to send chunk ack: %w", err)
+			}
+			continue
+		}
+
+		return msg, nil
+	}
+}
+
+func (c *Client) GetPluginInfo() (*pb.PluginInfo, error) {
+	msg, err := c.roundTrip(&pb.PluginInfoRequest{})
+	if err != nil {
+		return nil, err
+	}
+	response, err := expect[*pb.PluginInfo](msg)
+	if err != nil {
+		return nil, err
+	}
+	if response.ErrorMessage != "" {
+		return nil, errors.New(response.ErrorMessage)
+	}
+	return response, nil
+}
+
+// ExecuteCommand runs a command. Data the plugin streams as chunks is
+// discarded; use ExecuteCommandStream or StreamCommand to receive it.
+func (c *Client) ExecuteCommand(command string, parameters map[string]string) (*pb.CommandResponse, error) {
+	return c.ExecuteCommandStream(command, parameters, io.Discard)
+}
+
+// GetMenu returns the plugin's menu decoded from its JSON menu data.
+func (c *Client) GetMenu() ([]gsplug.MenuOption, error) {
+	msg, err := c.roundTrip(&pb.MenuRequest{})
+	if err != nil {
+		return nil, err
+	}
+	response, err := expect[*pb.MenuResponse](msg)
+	if err != nil {
+		return nil, err
+	}
+	if response.ErrorMessage != "" {
+		return nil, errors.New(response.ErrorMessage)
+	}
+
+	var menu []gsplug.MenuOption
+	if err := json.Unmarshal(response.MenuData, &menu); err != nil {
+		return nil, fmt.Errorf("failed to decode menu: %w", err)
+	}
+	return menu, nil
+}
+
+func (c *Client) GetSubscriptions() ([]gsplug.EventType, error) {
+	msg, err := c.roundTrip(&pb.SubscriptionRequest{})
+	if err != nil {
+		return nil, err
+	}
+	response, err := expect[*pb.SubscriptionResponse](msg)
+	if err != nil {
+		return nil, err
+	}
+	if response.ErrorMessage != "" {
+		return nil, errors.New(response.ErrorMessage)
+	}
+
+	events := subscriptionEvents(response)
+	c.mu.Lock()
+	c.delivered.subscriptions, c.delivered.subscribed = events, true
+	c.mu.Unlock()
+	return events, nil
+}
+
+// Subscriptions returns the events the plugin subscribed to when
+// GetSubscriptions was last called, kept current by Reload and restarts,
+// or nil before the first call.
+func (c *Client) Subscriptions() []gsplug.EventType {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.delivered.subscriptions
+}
+
+func subscriptionEvents(response *pb.SubscriptionResponse) []gsplug.EventType {
+	events := make([]gsplug.EventType, len(response.Events))
+	for i, event := range response.Events {
+		events[i] = gsplug.EventType(event)
+	}
+	return events
+}
+
+func (c *Client) SendEvent(event *pb.Event) (*pb.EventResponse, error) {
+	msg, err := c.roundTrip(event)
+	if err != nil {
+		return nil, err
+	}
+	return expect[*pb.EventResponse](msg)
+}
+
+// GetConfig returns the plugin's settings schema and current values.
+func (c *Client) GetConfig() ([]gsplug.ParameterInfo, map[string]string, error) {
+	msg, err := c.roundTrip(&pb.ConfigRequest{})
+	if err != nil {
+		return nil, nil, err
+	}
+	response, err := expect[*pb.ConfigResponse](msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if response.ErrorMessage != "" {
+		return nil, nil, errors.New(response.ErrorMessage)
+	}
+
+	var schema []gsplug.ParameterInfo
+	if len(response.SchemaData) > 0 {
+		if err := json.Unmarshal(response.SchemaData, &schema); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode config schema: %w", err)
+		}
+	}
+	return schema, response.Values, nil
+}
+
+// UpdateConfig changes the plugin's settings. The values are sent again to
+// the process started by Reload or a restart.
+func (c *Client) UpdateConfig(values map[string]string) error {
+	msg, err := c.roundTrip(&pb.UpdateConfigRequest{Values: values})
+	if err != nil {
+		return err
+	}
+	response, err := expect[*pb.UpdateConfigResponse](msg)
+	if err != nil {
+		return err
+	}
+	if !response.Success {
+		return errors.New(response.ErrorMessage)
+	}
+	c.remember(&c.delivered.config, values)
+	return nil
+}
+
+// SetSecrets delivers secrets declared in the plugin's manifest. They are
+// delivered again to the process started by Reload or a restart.
+func (c *Client) SetSecrets(values map[string]string) error {
+	msg, err := c.roundTrip(&pb.SecretsRequest{Values: values})
+	if err != nil {
+		return err
+	}
+	response, err := expect[*pb.SecretsResponse](msg)
+	if err != nil {
+		return err
+	}
+	if !response.Success {
+		return errors.New(response.ErrorMessage)
+	}
+	c.remember(&c.delivered.secrets, values)
+	return nil
+}
+
+// remember merges values the plugin accepted into saved.
+func (c *Client) remember(saved *map[string]string, values map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if *saved == nil {
+		*saved = make(map[string]string, len(values))
+	}
+	for name, value := range values {
+		(*saved)[name] = value
+	}
+}
+
+func expect[T proto.Message](msg proto.Message) (T, error) {
+	response, ok := msg.(T)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("unexpected response type %T", msg)
+	}
+	return response, nil
+}
@@ -0,0 +1,89 @@
+package host_test
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	"github.com/ssotops/gitspace-plugin-sdk/host"
+)
+
+func TestClientRequests(t *testing.T) {
+	client := startPlugin(t, "ok", host.Options{})
+
+	info, err := client.GetPluginInfo()
+	if err != nil || info.Name != "test-plugin" {
+		t.Fatalf("GetPluginInfo = %v, %v", info, err)
+	}
+	menu, err := client.GetMenu()
+	if err != nil || len(menu) != 1 || menu[0].Command != "echo" {
+		t.Fatalf("GetMenu = %v, %v", menu, err)
+	}
+	events, err := client.GetSubscriptions()
+	if err != nil || !reflect.DeepEqual(events, []gsplug.EventType{gsplug.EventRepoSynced}) {
+		t.Fatalf("GetSubscriptions = %v, %v", events, err)
+	}
+	response, err := client.ExecuteCommand("echo", map[string]string{"text": "hi"})
+	if err != nil || !response.Success || response.Result != "hi" || response.RequestId == "" {
+		t.Fatalf("ExecuteCommand = %v, %v", response, err)
+	}
+}
+
+func TestClientReportsHandlerErrors(t *testing.T) {
+	client := startPlugin(t, "broken", host.Options{})
+
+	if _, err := client.GetPluginInfo(); err == nil || err.Error() != "info unavailable" {
+		t.Errorf("GetPluginInfo error = %v", err)
+	}
+	if _, err := client.GetMenu(); err == nil || err.Error() != "menu unavailable" {
+		t.Errorf("GetMenu error = %v", err)
+	}
+	if _, err := client.GetSubscriptions(); err == nil || err.Error() != "subscriptions unavailable" {
+		t.Errorf("GetSubscriptions error = %v", err)
+	}
+
+	response, err := client.ExecuteCommand("nope", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Success || response.ErrorMessage != "command failed: nope" || response.RequestId == "" {
+		t.Fatalf("failed command answered %+v", response)
+	}
+
+	// The plugin keeps serving after failed requests.
+	if response, err := client.ExecuteCommand("echo", map[string]string{"text": "still here"}); err != nil || response.Result != "still here" {
+		t.Fatalf("ExecuteCommand = %v, %v", response, err)
+	}
+}
+
+func TestProtocolNegotiation(t *testing.T) {
+	tests := []struct {
+		name string
+		opts host.Options
+		env  map[string]string
+	}{
+		{"defaults", host.Options{}, map[string]string{gsplug.FramingEnv: "", gsplug.CompressionEnv: "", gsplug.CodecEnv: ""}},
+		{"gzip", host.Options{Compression: gsplug.CompressionGzip}, map[string]string{gsplug.CompressionEnv: "gzip"}},
+		{"checked gzip", host.Options{Framing: gsplug.FramingChecked, Compression: gsplug.CompressionGzip}, map[string]string{gsplug.FramingEnv: "checked", gsplug.CompressionEnv: "gzip"}},
+		{"json", host.Options{Codec: gsplug.CodecJSON}, map[string]string{gsplug.CodecEnv: "json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := startPlugin(t, "ok", tt.opts)
+
+			for name, want := range tt.env {
+				response, err := client.ExecuteCommand("env", map[string]string{"name": name})
+				if err != nil || response.Result != want {
+					t.Fatalf("plugin saw %s=%q (%v), want %q", name, response.GetResult(), err, want)
+				}
+			}
+			// Large responses, compressed when negotiated, arrive intact.
+			const size = 1 << 20
+			response, err := client.ExecuteCommand("big", map[string]string{"size": strconv.Itoa(size)})
+			if err != nil || len(response.Result) != size {
+				t.Fatalf("big response: %d bytes, %v", len(response.GetResult()), err)
+			}
+		})
+	}
+}
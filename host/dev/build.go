@@ -0,0 +1,309 @@
+package dev
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+)
+
+var (
+	pluginNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+	versionPattern    = regexp.MustCompile(`^v?\d+\.\d+\.\d+([-+][0-9A-Za-z.+-]+)?$`)
+)
+
+// ValidateManifest checks what installing needs beyond LoadManifest: a name
+// usable as a directory, a semantic version, distinct platforms and sources
+// and assets that exist in dir.
+func ValidateManifest(dir string, manifest *gsplug.Manifest) error {
+	name := manifest.Metadata.Name
+	if !pluginNamePattern.MatchString(name) {
+		return fmt.Errorf("manifest name %q must be letters, digits, dashes and underscores", name)
+	}
+	if !versionPattern.MatchString(manifest.Metadata.Version) {
+		return fmt.Errorf("manifest version %q is not a semantic version such as 1.2.3", manifest.Metadata.Version)
+	}
+	seen := make(map[gsplug.Platform]bool)
+	for _, platform := range manifest.Platforms {
+		if seen[platform] {
+			return fmt.Errorf("manifest lists platform %s twice", platform)
+		}
+		seen[platform] = true
+	}
+	for _, source := range manifest.Sources {
+		if _, err := os.Stat(filepath.Join(dir, source.Path)); err != nil {
+			return fmt.Errorf("manifest source %s: %w", source.Path, err)
+		}
+	}
+	if _, err := assetFiles(dir, manifest); err != nil {
+		return err
+	}
+	return nil
+}
+
+// assetFiles expands the manifest's asset patterns to files relative to dir.
+func assetFiles(dir string, manifest *gsplug.Manifest) ([]string, error) {
+	var files []string
+	for _, pattern := range manifest.Assets {
+		if filepath.IsAbs(pattern) || strings.HasPrefix(filepath.Clean(pattern), "..") {
+			return nil, fmt.Errorf("asset %q must be inside the plugin directory", pattern)
+		}
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid asset pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("asset %q matches no files", pattern)
+		}
+		for _, match := range matches {
+			err := filepath.Walk(match, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if info.Mode().IsRegular() {
+					rel, _ := filepath.Rel(dir, path)
+					files = append(files, rel)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read asset %s: %w", match, err)
+			}
+		}
+	}
+	return files, nil
+}
+
+// BuildOptions configures BuildPlugin.
+type BuildOptions struct {
+	// Targets to build for; empty means the manifest's platforms, or the
+	// current platform when it lists none.
+	Targets []gsplug.Platform
+	// OutDir receives one directory per target; empty means "dist" in the
+	// plugin directory.
+	OutDir string
+	// Stderr receives go build's output as it runs.
+	Stderr io.Writer
+}
+
+// BuildResult describes a successful BuildPlugin.
+type BuildResult struct {
+	Dir      string
+	Manifest *gsplug.Manifest
+	// Binaries maps each target to the binary built for it.
+	Binaries map[gsplug.Platform]string
+}
+
+// BuildPlugin validates the plugin's manifest and builds it for each target
+// with CGO disabled. The manifest version is embedded with
+// -ldflags "-X main.version=...", which sets a version variable in the
+// plugin's main package if it declares one.
+func BuildPlugin(ctx context.Context, dir string, opts BuildOptions) (*BuildResult, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := gsplug.LoadManifest(filepath.Join(dir, gsplug.ManifestFileName))
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateManifest(dir, manifest); err != nil {
+		return nil, err
+	}
+
+	targets := opts.Targets
+	if len(targets) == 0 {
+		targets = manifest.Platforms
+	}
+	if len(targets) == 0 {
+		targets = []gsplug.Platform{gsplug.CurrentPlatform}
+	}
+	outDir := opts.OutDir
+	if outDir == "" {
+		outDir = filepath.Join(dir, "dist")
+	}
+
+	result := &BuildResult{
+		Dir:      dir,
+		Manifest: manifest,
+		Binaries: make(map[gsplug.Platform]string, len(targets)),
+	}
+	ldflags := "-X main.version=" + manifest.Metadata.Version
+	for _, target := range targets {
+		binary := filepath.Join(outDir, target.Dir(), target.BinaryName(manifest.Metadata.Name))
+
+		cmd := exec.CommandContext(ctx, "go", "build", "-trimpath", "-ldflags", ldflags, "-o", binary, ".")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOOS="+target.OS, "GOARCH="+target.Arch, "CGO_ENABLED=0")
+		var output bytes.Buffer
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		if opts.Stderr != nil {
+			cmd.Stderr = io.MultiWriter(&output, opts.Stderr)
+		}
+		if err := cmd.Run(); err != nil {
+			return nil, &BuildError{
+				Output: strings.TrimSpace(output.String()),
+				Err:    fmt.Errorf("building for %s: %w", target, err),
+			}
+		}
+		result.Binaries[target] = binary
+	}
+	return result, nil
+}
+
+// Install copies the plugin's binaries, the manifest and the manifest's
+// assets into pluginsDir/<name>. A manifest that lists platforms gets every
+// platform's binary under bin/<os>_<arch>/, all of which must have been
+// built; otherwise the binary for the current platform is installed next to
+// the manifest. Assets keep their file modes. The files are written to a
+// hidden directory in pluginsDir and pluginsDir/<name> is a symlink to it,
+// replaced with a single rename, so Gitspace never sees a partly installed
+// plugin. It returns the installed directory.
+func Install(result *BuildResult, pluginsDir string) (string, error) {
+	name := result.Manifest.Metadata.Name
+	binaries := make(map[string]string)
+	if len(result.Manifest.Platforms) == 0 {
+		binary, ok := result.Binaries[gsplug.CurrentPlatform]
+		if !ok {
+			return "", fmt.Errorf("no binary was built for %s", gsplug.CurrentPlatform)
+		}
+		binaries[binary] = filepath.Base(binary)
+	}
+	for _, platform := range result.Manifest.Platforms {
+		binary, ok := result.Binaries[platform]
+		if !ok {
+			return "", fmt.Errorf("no binary was built for %s, which the manifest lists", platform)
+		}
+		binaries[binary] = filepath.Join(gsplug.PlatformsDir, platform.Dir(), filepath.Base(binary))
+	}
+
+	if err := os.MkdirAll(pluginsDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create plugins directory: %w", err)
+	}
+	staging, err := os.MkdirTemp(pluginsDir, installDirPrefix(name))
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	installed := false
+	defer func() {
+		if !installed {
+			os.RemoveAll(staging)
+		}
+	}()
+
+	for binary, rel := range binaries {
+		if err := copyFile(binary, filepath.Join(staging, rel), 0755); err != nil {
+			return "", err
+		}
+	}
+	if err := copyFile(filepath.Join(result.Dir, gsplug.ManifestFileName), filepath.Join(staging, gsplug.ManifestFileName), 0644); err != nil {
+		return "", err
+	}
+	assets, err := assetFiles(result.Dir, result.Manifest)
+	if err != nil {
+		return "", err
+	}
+	for _, asset := range assets {
+		src := filepath.Join(result.Dir, asset)
+		info, err := os.Stat(src)
+		if err != nil {
+			return "", fmt.Errorf("failed to read asset %s: %w", asset, err)
+		}
+		if err := copyFile(src, filepath.Join(staging, asset), info.Mode().Perm()); err != nil {
+			return "", err
+		}
+	}
+
+	target := filepath.Join(pluginsDir, name)
+	if err := swapLink(staging, target); err != nil {
+		return "", err
+	}
+	installed = true
+	return target, nil
+}
+
+// installDirPrefix starts the names of the hidden directories Install
+// writes the plugin called name to.
+func installDirPrefix(name string) string {
+	return "." + name + "-install-"
+}
+
+// isInstallLink reports whether the symlink dest, read from the link to
+// the plugin called name, points at a directory written by Install rather
+// than at a working copy linked by Link.
+func isInstallLink(name, dest string) bool {
+	return filepath.Dir(dest) == "." && strings.HasPrefix(dest, installDirPrefix(name))
+}
+
+// swapLink points target at staging by renaming a new symlink over it. The
+// directory of a previous install is removed afterwards; a link made by Link
+// is replaced but its working copy is left alone. A directory installed by
+// older versions of Install is moved aside first, so only that one upgrade
+// is not atomic, and is restored if the swap fails.
+func swapLink(staging, target string) error {
+	if err := os.Chmod(staging, 0755); err != nil {
+		return fmt.Errorf("failed to prepare %s: %w", staging, err)
+	}
+	link := staging + ".link"
+	if err := os.Symlink(filepath.Base(staging), link); err != nil {
+		return fmt.Errorf("failed to link %s: %w", staging, err)
+	}
+
+	var previous, movedAside string
+	if info, err := os.Lstat(target); err == nil {
+		if info.Mode()&os.ModeSymlink != 0 {
+			dest, err := os.Readlink(target)
+			if err == nil && isInstallLink(filepath.Base(target), dest) {
+				previous = filepath.Join(filepath.Dir(target), dest)
+			}
+		} else {
+			movedAside = staging + ".previous"
+			if err := os.Rename(target, movedAside); err != nil {
+				os.Remove(link)
+				return fmt.Errorf("failed to move aside %s: %w", target, err)
+			}
+			previous = movedAside
+		}
+	}
+
+	if err := os.Rename(link, target); err != nil {
+		os.Remove(link)
+		if movedAside != "" {
+			os.Rename(movedAside, target)
+		}
+		return fmt.Errorf("failed to install %s: %w", target, err)
+	}
+	if previous != "" {
+		os.RemoveAll(previous)
+	}
+	return nil
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(dst), err)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	return out.Close()
+}
@@ -0,0 +1,159 @@
+package dev_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	"github.com/ssotops/gitspace-plugin-sdk/host/dev"
+)
+
+// buildResult fakes the result of building a plugin called name, whose
+// binary prints version, with an executable script and a template as assets.
+func buildResult(t *testing.T, name, version string) *dev.BuildResult {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]os.FileMode{
+		gsplug.ManifestFileName: 0644,
+		name:                    0755,
+		"scripts/run.sh":        0755,
+		"templates/page.html":   0644,
+	}
+	for file, mode := range files {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(version), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &dev.BuildResult{
+		Dir: dir,
+		Manifest: &gsplug.Manifest{
+			Metadata: gsplug.ManifestMetadata{Name: name, Version: version},
+			Assets:   []string{"scripts/*", "templates"},
+		},
+		Binaries: map[gsplug.Platform]string{gsplug.CurrentPlatform: filepath.Join(dir, name)},
+	}
+}
+
+// pluginsDirEntries lists the names in pluginsDir.
+func pluginsDirEntries(t *testing.T, pluginsDir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func checkInstalled(t *testing.T, installed, version string) {
+	t.Helper()
+	binary, err := os.ReadFile(filepath.Join(installed, "greeter"))
+	if err != nil || string(binary) != version {
+		t.Fatalf("installed binary holds %q (%v), want %q", binary, err, version)
+	}
+	for file, want := range map[string]os.FileMode{"greeter": 0755, "scripts/run.sh": 0755, "templates/page.html": 0644} {
+		info, err := os.Stat(filepath.Join(installed, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got&0100 != want&0100 {
+			t.Errorf("%s installed with mode %v, want %v", file, got, want)
+		}
+	}
+}
+
+func TestInstallSwapsLink(t *testing.T) {
+	pluginsDir := t.TempDir()
+
+	installed, err := dev.Install(buildResult(t, "greeter", "1.0.0"), pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkInstalled(t, installed, "1.0.0")
+	first, err := os.Readlink(installed)
+	if err != nil {
+		t.Fatalf("installed plugin is not a link: %v", err)
+	}
+
+	if _, err := dev.Install(buildResult(t, "greeter", "1.1.0"), pluginsDir); err != nil {
+		t.Fatal(err)
+	}
+	checkInstalled(t, installed, "1.1.0")
+	second, err := os.Readlink(installed)
+	if err != nil || second == first {
+		t.Fatalf("reinstall left the link at %q (%v)", second, err)
+	}
+	// Only the link and the directory it points at remain.
+	if entries := pluginsDirEntries(t, pluginsDir); len(entries) != 2 || !contains(entries, second) {
+		t.Fatalf("plugins directory holds %q after reinstalling", entries)
+	}
+}
+
+func TestInstallReplacesDirectory(t *testing.T) {
+	pluginsDir := t.TempDir()
+	old := filepath.Join(pluginsDir, "greeter")
+	if err := os.MkdirAll(old, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(old, "greeter"), []byte("0.9.0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	installed, err := dev.Install(buildResult(t, "greeter", "1.0.0"), pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkInstalled(t, installed, "1.0.0")
+	if entries := pluginsDirEntries(t, pluginsDir); len(entries) != 2 {
+		t.Fatalf("plugins directory holds %q after replacing a copied install", entries)
+	}
+}
+
+func TestInstallReplacesDevLink(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	pluginsDir, err := gsplug.GetPluginsDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	workingCopy := t.TempDir()
+	if _, err := dev.Link(workingCopy, "greeter"); err != nil {
+		t.Fatal(err)
+	}
+
+	installed, err := dev.Install(buildResult(t, "greeter", "1.0.0"), pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkInstalled(t, installed, "1.0.0")
+	if _, err := os.Stat(workingCopy); err != nil {
+		t.Fatalf("installing over a link removed the working copy: %v", err)
+	}
+
+	// Link and Unlink leave the installed plugin alone.
+	if _, err := dev.Link(workingCopy, "greeter"); err == nil || !strings.Contains(err.Error(), "installed plugin") {
+		t.Fatalf("Link over an installed plugin: %v", err)
+	}
+	if err := dev.Unlink("greeter"); err == nil || !strings.Contains(err.Error(), "installed plugin") {
+		t.Fatalf("Unlink of an installed plugin: %v", err)
+	}
+	checkInstalled(t, installed, "1.0.0")
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,352 @@
+// Package dev is the tooling behind gsplug for working on a plugin from its
+// source directory: building and installing it, and running it with hot
+// reload.
+package dev
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	"github.com/ssotops/gitspace-plugin-sdk/host"
+)
+
+// DefaultPollInterval is how often a Session checks the source for changes
+// when its PollInterval is zero.
+const DefaultPollInterval = 500 * time.Millisecond
+
+// BuildError is returned when go build fails. Output holds the compiler's
+// messages.
+type BuildError struct {
+	Output string
+	Err    error
+}
+
+func (e *BuildError) Error() string {
+	return fmt.Sprintf("build failed: %v\n%s", e.Err, e.Output)
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
+// EventKind says what happened after a source change.
+type EventKind string
+
+const (
+	EventReloaded     EventKind = "reloaded"
+	EventBuildFailed  EventKind = "build_failed"
+	EventReloadFailed EventKind = "reload_failed"
+)
+
+// Event reports the outcome of a rebuild.
+type Event struct {
+	Kind     EventKind
+	Changed  []string
+	Duration time.Duration
+	Err      error
+}
+
+// Session builds a plugin with go build, runs it and swaps in a new process
+// after each successful rebuild. The binary is written into the source
+// directory where host.ResolveBinary looks for it, under the manifest's
+// name or in bin/<os>_<arch>/ when the manifest lists platforms, so a
+// linked working copy is always current.
+type Session struct {
+	Dir          string
+	Options      host.Options
+	PollInterval time.Duration
+
+	manifest *gsplug.Manifest
+	binary   string
+	client   *host.Client
+	// rebuildMu makes Watch and Rebuild take turns building and reloading.
+	rebuildMu sync.Mutex
+}
+
+// NewSession prepares a session for the plugin in dir, which must contain
+// a manifest.
+func NewSession(dir string, opts host.Options) (*Session, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := gsplug.LoadManifest(filepath.Join(dir, gsplug.ManifestFileName))
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Metadata.Name == "" {
+		return nil, fmt.Errorf("manifest in %s has no name", dir)
+	}
+
+	return &Session{
+		Dir:      dir,
+		Options:  opts,
+		manifest: manifest,
+		binary:   host.BinaryPath(dir, manifest),
+	}, nil
+}
+
+func (s *Session) Name() string {
+	return s.manifest.Metadata.Name
+}
+
+// Binary returns the path go build writes the plugin to.
+func (s *Session) Binary() string {
+	return s.binary
+}
+
+// Client returns the client connected to the running plugin, or nil before
+// Start.
+func (s *Session) Client() *host.Client {
+	return s.client
+}
+
+// Build compiles the plugin. Compiler errors are returned as *BuildError.
+func (s *Session) Build(ctx context.Context) error {
+	if err := os.MkdirAll(filepath.Dir(s.binary), 0755); err != nil {
+		return fmt.Errorf("failed to create binary directory: %w", err)
+	}
+	ldflags := "-X main.version=" + s.manifest.Metadata.Version
+	cmd := exec.CommandContext(ctx, "go", "build", "-ldflags", ldflags, "-o", s.binary, ".")
+	cmd.Dir = s.Dir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return &BuildError{Output: strings.TrimSpace(output.String()), Err: err}
+	}
+	return nil
+}
+
+// Start builds the plugin and launches it.
+func (s *Session) Start(ctx context.Context) (*host.Client, error) {
+	if err := s.Build(ctx); err != nil {
+		return nil, err
+	}
+	client, err := host.Start(s.binary, s.Options)
+	if err != nil {
+		return nil, err
+	}
+	s.client = client
+	return client, nil
+}
+
+// Rebuild builds the plugin and reloads the running process. A failed build
+// leaves the old process running. It is safe to call while Watch runs.
+func (s *Session) Rebuild(ctx context.Context) error {
+	s.rebuildMu.Lock()
+	defer s.rebuildMu.Unlock()
+
+	if err := s.Build(ctx); err != nil {
+		return err
+	}
+	return s.client.Reload()
+}
+
+// Watch polls the source directory until ctx is done and rebuilds after
+// every change, reporting each outcome on events.
+func (s *Session) Watch(ctx context.Context, events chan<- Event) error {
+	interval := s.PollInterval
+	if interval == 0 {
+		interval = DefaultPollInterval
+	}
+
+	last, err := s.snapshot()
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		current, err := s.snapshot()
+		if err != nil {
+			return err
+		}
+		changed := diff(last, current)
+		if len(changed) == 0 {
+			continue
+		}
+		last = current
+
+		event := s.rebuild(ctx, changed)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// rebuild builds and reloads the plugin after changed files, taking turns
+// with Rebuild.
+func (s *Session) rebuild(ctx context.Context, changed []string) Event {
+	s.rebuildMu.Lock()
+	defer s.rebuildMu.Unlock()
+
+	start := time.Now()
+	event := Event{Kind: EventReloaded, Changed: changed}
+	if err := s.Build(ctx); err != nil {
+		event.Kind, event.Err = EventBuildFailed, err
+	} else if err := s.client.Reload(); err != nil {
+		event.Kind, event.Err = EventReloadFailed, err
+	}
+	event.Duration = time.Since(start)
+	return event
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshot records the source files that affect the build: everything but
+// the binary, build output in dist and bin, and hidden files.
+func (s *Session) snapshot() (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	err := filepath.WalkDir(s.Dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != s.Dir && (strings.HasPrefix(entry.Name(), ".") || entry.IsDir() && s.isBuildOutput(path)) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || path == s.binary {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		rel, _ := filepath.Rel(s.Dir, path)
+		files[rel] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", s.Dir, err)
+	}
+	return files, nil
+}
+
+// isBuildOutput reports whether dir holds binaries rather than source.
+func (s *Session) isBuildOutput(dir string) bool {
+	switch rel, _ := filepath.Rel(s.Dir, dir); rel {
+	case "dist":
+		return true
+	case gsplug.PlatformsDir:
+		return len(s.manifest.Platforms) > 0
+	}
+	return false
+}
+
+func diff(before, after map[string]fileState) []string {
+	var changed []string
+	for path, state := range after {
+		if before[path] != state {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
+
+// Close stops the plugin.
+func (s *Session) Close() error {
+	if s.client == nil {
+		return nil
+	}
+	return s.client.Close()
+}
+
+// Link makes the working copy in dir an installed plugin by symlinking it
+// into the plugins directory under name. An existing link is replaced; a
+// plugin installed with Install is left alone and reported as an error.
+func Link(dir, name string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	pluginsDir, err := gsplug.GetPluginsDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(pluginsDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create plugins directory: %w", err)
+	}
+
+	link := filepath.Join(pluginsDir, name)
+	if _, err := os.Lstat(link); err == nil {
+		if isInstalled(link, name) {
+			return "", fmt.Errorf("%s is an installed plugin, not a link; remove it first", link)
+		}
+		if err := os.Remove(link); err != nil {
+			return "", fmt.Errorf("failed to replace link %s: %w", link, err)
+		}
+	}
+	if err := os.Symlink(dir, link); err != nil {
+		return "", fmt.Errorf("failed to link %s: %w", link, err)
+	}
+	return link, nil
+}
+
+// Unlink removes a link created by Link. It refuses to remove an installed
+// copy.
+func Unlink(name string) error {
+	pluginsDir, err := gsplug.GetPluginsDir()
+	if err != nil {
+		return err
+	}
+	link := filepath.Join(pluginsDir, name)
+	if _, err := os.Lstat(link); err != nil {
+		return fmt.Errorf("failed to unlink %s: %w", name, err)
+	}
+	if isInstalled(link, name) {
+		return fmt.Errorf("%s is an installed plugin, not a link", link)
+	}
+	return os.Remove(link)
+}
+
+// isInstalled reports whether the plugins directory entry path holds a
+// plugin installed with Install rather than a link made by Link.
+func isInstalled(path, name string) bool {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return true
+	}
+	dest, err := os.Readlink(path)
+	return err == nil && isInstallLink(name, dest)
+}
@@ -0,0 +1,217 @@
+package dev_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ssotops/gitspace-plugin-sdk/host"
+	"github.com/ssotops/gitspace-plugin-sdk/host/dev"
+)
+
+const pluginSource = `package main
+
+import (
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+type plugin struct{}
+
+func (plugin) GetPluginInfo(*pb.PluginInfoRequest) (*pb.PluginInfo, error) {
+	return &pb.PluginInfo{Name: "devtest"}, nil
+}
+
+func (plugin) ExecuteCommand(*pb.CommandRequest) (*pb.CommandResponse, error) {
+	return &pb.CommandResponse{Success: true, Result: %q}, nil
+}
+
+func (plugin) GetMenu(*pb.MenuRequest) (*pb.MenuResponse, error) {
+	return &pb.MenuResponse{}, nil
+}
+
+func main() {
+	gsplug.RunPlugin(plugin{})
+}
+`
+
+// newPluginDir writes a plugin module using this SDK that answers every
+// command with result.
+func newPluginDir(t *testing.T, result string) string {
+	t.Helper()
+	sdk, err := filepath.Abs("../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := os.ReadFile(filepath.Join(sdk, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module devtest\n\ngo 1.23.1\n\n" +
+			"require github.com/ssotops/gitspace-plugin-sdk v0.0.0-00010101000000-000000000000\n\n" +
+			"replace github.com/ssotops/gitspace-plugin-sdk => " + sdk + "\n",
+		"go.sum":               string(sum),
+		"gitspace-plugin.toml": "[metadata]\nname = \"devtest\"\nversion = \"1.0.0\"\n\n[[sources]]\npath = \"main.go\"\nentry_point = \"main\"\n",
+		"main.go":              fmt.Sprintf(pluginSource, result),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// startSession builds and starts the plugin in dir, building offline from
+// the module cache when possible.
+func startSession(t *testing.T, dir string) *dev.Session {
+	t.Helper()
+	t.Setenv("GOFLAGS", "-mod=mod")
+	session, err := dev.NewSession(dir, host.Options{Stderr: os.Stderr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.PollInterval = 20 * time.Millisecond
+	if _, err := session.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start plugin: %v", err)
+	}
+	t.Cleanup(func() { session.Close() })
+	return session
+}
+
+// writeSource rewrites the plugin's main.go with a modification time after
+// the previous one, so Watch sees the change.
+func writeSource(t *testing.T, dir, source string, n int) {
+	t.Helper()
+	path := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stamp := time.Now().Add(time.Duration(n) * time.Second)
+	if err := os.Chtimes(path, stamp, stamp); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitForWatch gives Watch time to take its first snapshot, so the changes
+// made next are seen as changes.
+func waitForWatch(session *dev.Session) {
+	time.Sleep(10 * session.PollInterval)
+}
+
+func result(t *testing.T, session *dev.Session) string {
+	t.Helper()
+	response, err := session.Client().ExecuteCommand("check", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response.Result
+}
+
+func TestSessionWatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a plugin")
+	}
+	dir := newPluginDir(t, "v1")
+	session := startSession(t, dir)
+	if got := result(t, session); got != "v1" {
+		t.Fatalf("plugin answered %q, want v1", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan dev.Event)
+	watchErr := make(chan error, 1)
+	go func() { watchErr <- session.Watch(ctx, events) }()
+	waitForWatch(session)
+	defer func() {
+		cancel()
+		if err := <-watchErr; !errors.Is(err, context.Canceled) {
+			t.Errorf("Watch returned %v", err)
+		}
+	}()
+	next := func() dev.Event {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(time.Minute):
+			t.Fatal("no event after changing the source")
+			return dev.Event{}
+		}
+	}
+
+	// A broken build leaves the running version in place.
+	writeSource(t, dir, "package main\n\nfunc main() {", 1)
+	event := next()
+	var buildErr *dev.BuildError
+	if event.Kind != dev.EventBuildFailed || !errors.As(event.Err, &buildErr) {
+		t.Fatalf("got %s event (%v), want a build failure", event.Kind, event.Err)
+	}
+	if got := result(t, session); got != "v1" {
+		t.Fatalf("plugin answered %q after a failed build, want v1", got)
+	}
+
+	writeSource(t, dir, fmt.Sprintf(pluginSource, "v2"), 2)
+	if event := next(); event.Kind != dev.EventReloaded || len(event.Changed) != 1 || event.Changed[0] != "main.go" {
+		t.Fatalf("got %+v, want a reload after main.go changed", event)
+	}
+	if got := result(t, session); got != "v2" {
+		t.Fatalf("plugin answered %q after reloading, want v2", got)
+	}
+}
+
+func TestSessionRebuildWhileWatching(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a plugin")
+	}
+	dir := newPluginDir(t, "v1")
+	session := startSession(t, dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan dev.Event, 1)
+	watchErr := make(chan error, 1)
+	go func() { watchErr <- session.Watch(ctx, events) }()
+	waitForWatch(session)
+
+	writeSource(t, dir, fmt.Sprintf(pluginSource, "v2"), 1)
+	// Rebuilds typed at the prompt race the one Watch starts for the change.
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- session.Rebuild(ctx)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Rebuild: %v", err)
+		}
+	}
+
+	select {
+	case event := <-events:
+		if event.Kind != dev.EventReloaded {
+			t.Errorf("Watch reported %s: %v", event.Kind, event.Err)
+		}
+	case <-time.After(time.Minute):
+		t.Error("Watch did not rebuild after the change")
+	}
+	cancel()
+	<-watchErr
+
+	if got := result(t, session); got != "v2" {
+		t.Fatalf("plugin answered %q, want v2", got)
+	}
+}
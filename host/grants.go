@@ -0,0 +1,152 @@
+package host
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+)
+
+// Grant records the permissions a user approved for a plugin version.
+type Grant struct {
+	Plugin      string             `json:"plugin"`
+	Version     string             `json:"version"`
+	Permissions gsplug.Permissions `json:"permissions"`
+	GrantedAt   time.Time          `json:"granted_at"`
+}
+
+// ApproveFunc asks the user to approve a plugin's permissions.
+type ApproveFunc func(manifest *gsplug.Manifest) (bool, error)
+
+// ErrNotApproved is returned when the user rejects a plugin's permissions.
+var ErrNotApproved = errors.New("plugin permissions were not approved")
+
+func GetGrantPath(pluginName string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".ssot", "gitspace", "grants", pluginName+".json"), nil
+}
+
+// LoadGrant returns the stored grant for pluginName, or nil if none exists.
+func LoadGrant(pluginName string) (*Grant, error) {
+	path, err := GetGrantPath(pluginName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read grant: %w", err)
+	}
+
+	var grant Grant
+	if err := json.Unmarshal(data, &grant); err != nil {
+		return nil, fmt.Errorf("failed to decode grant: %w", err)
+	}
+	return &grant, nil
+}
+
+func SaveGrant(grant *Grant) error {
+	path, err := GetGrantPath(grant.Plugin)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create grants directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(grant, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode grant: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write grant: %w", err)
+	}
+	return nil
+}
+
+// EnsureGrant returns the stored grant for the manifest's plugin when it
+// already covers every declared permission. Otherwise it asks approve and
+// persists a new grant, returning ErrNotApproved if the user declines.
+func EnsureGrant(manifest *gsplug.Manifest, approve ApproveFunc) (*Grant, error) {
+	name := manifest.Metadata.Name
+
+	existing, err := LoadGrant(name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Permissions.Covers(manifest.Permissions) {
+		return existing, nil
+	}
+
+	if !manifest.Permissions.IsEmpty() {
+		approved, err := approve(manifest)
+		if err != nil {
+			return nil, err
+		}
+		if !approved {
+			return nil, ErrNotApproved
+		}
+	}
+
+	grant := &Grant{
+		Plugin:      name,
+		Version:     manifest.Metadata.Version,
+		Permissions: manifest.Permissions,
+		GrantedAt:   time.Now(),
+	}
+	if err := SaveGrant(grant); err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
+var (
+	permissionTitleStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("#FAFAFA")).
+				Background(lipgloss.Color("#874BFD")).
+				Padding(0, 1)
+
+	permissionStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#F9E2AF"))
+
+	permissionBorderStyle = lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("#89B4FA")).
+				Padding(1)
+)
+
+// PromptApproval returns an ApproveFunc that lists the requested permissions
+// on w and reads a yes/no answer from r.
+func PromptApproval(w io.Writer, r io.Reader) ApproveFunc {
+	return func(manifest *gsplug.Manifest) (bool, error) {
+		summary := strings.Builder{}
+		summary.WriteString(permissionTitleStyle.Render(fmt.Sprintf("%s %s requests:", manifest.Metadata.Name, manifest.Metadata.Version)) + "\n\n")
+		for _, line := range manifest.Permissions.Describe() {
+			summary.WriteString(permissionStyle.Render("  • "+line) + "\n")
+		}
+		fmt.Fprintln(w, permissionBorderStyle.Render(summary.String()))
+		fmt.Fprint(w, "Allow? (y/n) ")
+
+		answer, err := bufio.NewReader(r).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return false, fmt.Errorf("failed to read answer: %w", err)
+		}
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		return answer == "y" || answer == "yes", nil
+	}
+}
@@ -0,0 +1,86 @@
+package host_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	"github.com/ssotops/gitspace-plugin-sdk/host"
+)
+
+func TestEnsureGrant(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	manifest := &gsplug.Manifest{
+		Metadata:    gsplug.ManifestMetadata{Name: "grant-test", Version: "1.0.0"},
+		Permissions: gsplug.Permissions{Callbacks: []gsplug.Capability{gsplug.CapabilityRepositories}},
+	}
+	asked := 0
+	answer := true
+	approve := func(*gsplug.Manifest) (bool, error) {
+		asked++
+		return answer, nil
+	}
+
+	grant, err := host.EnsureGrant(manifest, approve)
+	if err != nil || asked != 1 || !grant.Permissions.HasCallback(gsplug.CapabilityRepositories) {
+		t.Fatalf("first run: grant %+v, err %v, asked %d times", grant, err, asked)
+	}
+	if stored, err := host.LoadGrant("grant-test"); err != nil || stored == nil || stored.Version != "1.0.0" {
+		t.Fatalf("stored grant %+v, %v", stored, err)
+	}
+
+	// An update with the same permissions is not asked about again.
+	manifest.Metadata.Version = "1.1.0"
+	if _, err := host.EnsureGrant(manifest, approve); err != nil || asked != 1 {
+		t.Fatalf("unchanged permissions: err %v, asked %d times", err, asked)
+	}
+
+	// New permissions are, and declining keeps the old grant.
+	manifest.Permissions.Callbacks = append(manifest.Permissions.Callbacks, gsplug.CapabilitySecrets)
+	answer = false
+	if _, err := host.EnsureGrant(manifest, approve); !errors.Is(err, host.ErrNotApproved) || asked != 2 {
+		t.Fatalf("declined: err %v, asked %d times", err, asked)
+	}
+	if stored, _ := host.LoadGrant("grant-test"); stored.Permissions.HasCallback(gsplug.CapabilitySecrets) {
+		t.Fatal("a declined permission was stored")
+	}
+
+	// Plugins without permissions are never asked about.
+	if _, err := host.EnsureGrant(&gsplug.Manifest{Metadata: gsplug.ManifestMetadata{Name: "no-permissions"}}, approve); err != nil || asked != 2 {
+		t.Fatalf("no permissions: err %v, asked %d times", err, asked)
+	}
+
+	if grant, err := host.LoadGrant("never-installed"); grant != nil || err != nil {
+		t.Fatalf("LoadGrant of an unknown plugin = %+v, %v", grant, err)
+	}
+}
+
+func TestPromptApproval(t *testing.T) {
+	manifest := &gsplug.Manifest{
+		Metadata:    gsplug.ManifestMetadata{Name: "prompt-test", Version: "1.0.0"},
+		Permissions: gsplug.Permissions{Network: []string{"api.github.com"}},
+	}
+	for answer, want := range map[string]bool{"y\n": true, "Yes\n": true, "n\n": false, "": false, "maybe\n": false} {
+		var prompt strings.Builder
+		approved, err := host.PromptApproval(&prompt, strings.NewReader(answer))(manifest)
+		if err != nil || approved != want {
+			t.Errorf("answer %q: approved %v, err %v", answer, approved, err)
+		}
+		if !strings.Contains(prompt.String(), "api.github.com") {
+			t.Errorf("prompt does not list the permissions: %s", prompt.String())
+		}
+	}
+
+	if _, err := host.PromptApproval(io.Discard, failingReader{})(manifest); err == nil {
+		t.Fatal("a read error was ignored")
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("terminal closed")
+}
@@ -0,0 +1,112 @@
+//go:build linux
+
+package host
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	landlockRead = unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_READ_DIR
+	landlockExec = landlockRead | unix.LANDLOCK_ACCESS_FS_EXECUTE
+	// landlockFile are the rights that apply to files rather than
+	// directories.
+	landlockFile = unix.LANDLOCK_ACCESS_FS_EXECUTE | unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_TRUNCATE
+)
+
+// landlockABI returns the kernel's Landlock ABI version, or 0 without
+// Landlock.
+func landlockABI() int {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0
+	}
+	return int(abi)
+}
+
+// landlockHandled returns the filesystem rights the kernel's ABI can
+// restrict.
+func landlockHandled(abi int) uint64 {
+	// Version 1 handles execute through make_sym.
+	handled := uint64(unix.LANDLOCK_ACCESS_FS_MAKE_SYM<<1 - 1)
+	if abi >= 2 {
+		handled |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		handled |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	return handled
+}
+
+// landlockRuleset is a Landlock ruleset being built. Rules refer to the
+// files their paths named when they were added, even if something is
+// mounted over them later.
+type landlockRuleset struct {
+	fd      int
+	handled uint64
+}
+
+func newLandlockRuleset() (*landlockRuleset, error) {
+	handled := landlockHandled(landlockABI())
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET,
+		uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return nil, fmt.Errorf("failed to create landlock ruleset: %w", errno)
+	}
+	return &landlockRuleset{fd: int(fd), handled: handled}, nil
+}
+
+// allow adds rules. Paths that do not exist are skipped.
+func (r *landlockRuleset) allow(rules ...pathRule) error {
+	for _, rule := range rules {
+		access := uint64(landlockRead)
+		switch rule.Access {
+		case pathExec:
+			access = landlockExec
+		case pathWrite:
+			access = r.handled
+		}
+		if err := addLandlockRule(r.fd, rule.Path, access&r.handled); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enforce confines the calling thread to the ruleset.
+func (r *landlockRuleset) enforce() error {
+	defer unix.Close(r.fd)
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, uintptr(r.fd), 0, 0); errno != 0 {
+		return fmt.Errorf("failed to enforce landlock ruleset: %w", errno)
+	}
+	return nil
+}
+
+func addLandlockRule(ruleset int, path string, access uint64) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	if !info.IsDir() {
+		access &= landlockFile
+	}
+
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s for landlock: %w", path, err)
+	}
+	defer unix.Close(fd)
+
+	attr := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+	if _, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(ruleset),
+		unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&attr)), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to allow %s: %w", path, errno)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package host
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultRequestTimeout bounds requests when Limits.RequestTimeout is zero,
+// so a plugin that never answers cannot hang the host.
+const DefaultRequestTimeout = 5 * time.Minute
+
+// Limits bounds the resources a plugin may use. Apart from MaxFrameSize and
+// RequestTimeout, zero values disable the corresponding limit.
+type Limits struct {
+	// MaxFrameSize rejects plugin messages larger than this many bytes.
+	// Zero means gsplug.DefaultMaxFrameSize.
+	MaxFrameSize uint32
+	// RequestTimeout bounds each request, including host calls made while
+	// handling it. Zero means DefaultRequestTimeout; a negative value
+	// disables the deadline.
+	RequestTimeout time.Duration
+	// MemoryBytes caps the plugin's memory with a cgroup v2 memory.max when
+	// the host may create cgroups, and with RLIMIT_DATA otherwise.
+	MemoryBytes uint64
+	// MaxConcurrentRequests rejects requests once this many are in flight
+	// or waiting for the plugin.
+	MaxConcurrentRequests int
+	// RestartOnViolation restarts the plugin after it was killed for
+	// exceeding a limit. Otherwise the client stays unusable.
+	RestartOnViolation bool
+}
+
+// LimitKind identifies which limit a plugin exceeded.
+type LimitKind string
+
+const (
+	LimitFrameSize   LimitKind = "frame_size"
+	LimitTimeout     LimitKind = "timeout"
+	LimitMemory      LimitKind = "memory"
+	LimitConcurrency LimitKind = "concurrency"
+)
+
+// LimitError reports a limit violation. For every kind except
+// LimitConcurrency the plugin process has been killed.
+type LimitError struct {
+	Plugin    string
+	Kind      LimitKind
+	Detail    string
+	Restarted bool
+	Err       error
+}
+
+func (e *LimitError) Error() string {
+	msg := fmt.Sprintf("plugin %s exceeded %s limit: %s", e.Plugin, e.Kind, e.Detail)
+	if e.Restarted {
+		msg += " (restarted)"
+	}
+	return msg
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,108 @@
+package host_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	"github.com/ssotops/gitspace-plugin-sdk/host"
+)
+
+// limitError returns the LimitError in err, failing the test if there is
+// none or it is of another kind.
+func limitError(t *testing.T, err error, kind host.LimitKind) *host.LimitError {
+	t.Helper()
+	var limitErr *host.LimitError
+	if !errors.As(err, &limitErr) || limitErr.Kind != kind {
+		t.Fatalf("got error %v, want a %s limit error", err, kind)
+	}
+	return limitErr
+}
+
+func TestRequestTimeout(t *testing.T) {
+	client := startPlugin(t, "ok", host.Options{Limits: host.Limits{RequestTimeout: 100 * time.Millisecond}})
+
+	if response, err := client.ExecuteCommand("sleep", map[string]string{"duration": "10ms"}); err != nil || response.Result != "slept" {
+		t.Fatalf("fast request: %v, %v", response, err)
+	}
+
+	start := time.Now()
+	_, err := client.ExecuteCommand("sleep", map[string]string{"duration": "10s"})
+	limitErr := limitError(t, err, host.LimitTimeout)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("timed out after %s", elapsed)
+	}
+	if limitErr.Restarted {
+		t.Fatal("restarted without RestartOnViolation")
+	}
+
+	if _, err := client.ExecuteCommand("echo", nil); !errors.Is(err, host.ErrPluginStopped) {
+		t.Fatalf("request to a stopped plugin: %v", err)
+	}
+}
+
+func TestRestartOnViolation(t *testing.T) {
+	client := startPlugin(t, "ok", host.Options{Limits: host.Limits{
+		RequestTimeout:     100 * time.Millisecond,
+		RestartOnViolation: true,
+	}})
+
+	_, err := client.ExecuteCommand("sleep", map[string]string{"duration": "10s"})
+	if limitErr := limitError(t, err, host.LimitTimeout); !limitErr.Restarted {
+		t.Fatalf("plugin was not restarted: %v", limitErr)
+	}
+	if response, err := client.ExecuteCommand("echo", map[string]string{"text": "back"}); err != nil || response.Result != "back" {
+		t.Fatalf("request after restart: %v, %v", response, err)
+	}
+}
+
+func TestFrameSizeLimit(t *testing.T) {
+	for _, codec := range []gsplug.CodecName{gsplug.CodecProtobuf, gsplug.CodecJSON} {
+		t.Run(string(codec), func(t *testing.T) {
+			client := startPlugin(t, "ok", host.Options{
+				Codec: codec,
+				Limits: host.Limits{
+					MaxFrameSize:       4 << 10,
+					RestartOnViolation: true,
+				},
+			})
+
+			if _, err := client.ExecuteCommand("big", map[string]string{"size": "1024"}); err != nil {
+				t.Fatalf("response under the limit: %v", err)
+			}
+			_, err := client.ExecuteCommand("big", map[string]string{"size": "65536"})
+			limitErr := limitError(t, err, host.LimitFrameSize)
+			if !errors.Is(limitErr, gsplug.ErrFrameTooLarge) {
+				t.Fatalf("limit error does not wrap ErrFrameTooLarge: %v", limitErr)
+			}
+			if response, err := client.ExecuteCommand("echo", map[string]string{"text": "back"}); err != nil || response.Result != "back" {
+				t.Fatalf("request after restart: %v, %v", response, err)
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	client := startPlugin(t, "ok", host.Options{Limits: host.Limits{MaxConcurrentRequests: 1}})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := client.ExecuteCommand("sleep", map[string]string{"duration": "300ms"}); err != nil {
+			t.Error(err)
+		}
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	_, err := client.ExecuteCommand("echo", nil)
+	limitError(t, err, host.LimitConcurrency)
+	wg.Wait()
+
+	// Rejected requests do not count, and the plugin was not killed.
+	if response, err := client.ExecuteCommand("echo", map[string]string{"text": "next"}); err != nil || response.Result != "next" {
+		t.Fatalf("request after the limit cleared: %v, %v", response, err)
+	}
+}
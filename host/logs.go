@@ -0,0 +1,96 @@
+package host
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+// LogRecordToSlog converts a plugin's forwarded log record to a slog record
+// whose attributes start with plugin, request_id and caller, when set,
+// followed by the record's own attributes.
+func LogRecordToSlog(record *pb.LogRecord) slog.Record {
+	out := slog.NewRecord(time.Unix(0, record.TimeUnixNano), slog.Level(record.Level), record.Message, 0)
+	if record.Plugin != "" {
+		out.AddAttrs(slog.String("plugin", record.Plugin))
+	}
+	if record.RequestId != "" {
+		out.AddAttrs(slog.String("request_id", record.RequestId))
+	}
+	if record.Caller != "" {
+		out.AddAttrs(slog.String("caller", record.Caller))
+	}
+	for _, attr := range record.Attrs {
+		out.AddAttrs(slog.String(attr.Key, attr.Value))
+	}
+	return out
+}
+
+// handleLog passes a forwarded record to Options.Logs.
+func (c *Client) handleLog(record *pb.LogRecord) {
+	handler := c.opts.Logs
+	if handler == nil {
+		return
+	}
+	ctx := context.Background()
+	if !handler.Enabled(ctx, slog.Level(record.Level)) {
+		return
+	}
+	if record.Plugin == "" {
+		record.Plugin = c.Name()
+	}
+	handler.Handle(ctx, LogRecordToSlog(record))
+}
+
+// drain reads what p writes after its stdin was closed until it exits,
+// passing log records to Options.Logs and discarding anything else.
+func (c *Client) drain(p process) {
+	for {
+		msgType, msg, err := c.codec.ReadPluginMessage(p.stdout)
+		if err != nil {
+			if gsplug.Recoverable(err) {
+				continue
+			}
+			return
+		}
+		if msgType == gsplug.MessageTypeLog {
+			c.handleLog(msg.(*pb.LogRecord))
+		}
+	}
+}
+
+// logCommand logs to Options.Logger that req was sent and returns a
+// function that logs its duration and outcome.
+func (c *Client) logCommand(req *pb.CommandRequest) func(*pb.CommandResponse, error) {
+	logger := c.opts.Logger
+	if logger == nil {
+		return func(*pb.CommandResponse, error) {}
+	}
+	logger = logger.With(
+		slog.String("plugin", c.Name()),
+		slog.String("request_id", req.RequestId),
+		slog.String("command", req.Command),
+	)
+	started := time.Now()
+	logger.Debug("command started")
+
+	return func(response *pb.CommandResponse, err error) {
+		level, outcome := slog.LevelInfo, "success"
+		attrs := []slog.Attr{slog.Duration("duration", time.Since(started))}
+		switch {
+		case err != nil:
+			level, outcome = slog.LevelError, "error"
+			attrs = append(attrs, slog.String("error", err.Error()))
+		case !response.Success:
+			level, outcome = slog.LevelWarn, "failure"
+			if response.ErrorMessage != "" {
+				attrs = append(attrs, slog.String("error", response.ErrorMessage))
+			}
+		}
+		attrs = append(attrs, slog.String("outcome", outcome))
+		logger.LogAttrs(context.Background(), level, "command finished", attrs...)
+	}
+}
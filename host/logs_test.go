@@ -0,0 +1,87 @@
+package host_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/host"
+)
+
+// recordHandler keeps the records passed to it.
+type recordHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordHandler) Handle(_ context.Context, record slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, record)
+	return nil
+}
+
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *recordHandler) WithGroup(string) slog.Handler { return h }
+
+// count returns how many records have message, and how many of those carry
+// requestID, when it is set.
+func (h *recordHandler) count(message, requestID string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := 0
+	for _, record := range h.records {
+		if record.Message != message {
+			continue
+		}
+		matches := requestID == ""
+		record.Attrs(func(attr slog.Attr) bool {
+			if attr.Key == "request_id" && attr.Value.String() == requestID {
+				matches = true
+			}
+			return true
+		})
+		if matches {
+			n++
+		}
+	}
+	return n
+}
+
+func TestForwardedLogs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	logs := &recordHandler{}
+	client := startPlugin(t, "logs", host.Options{Logs: logs})
+
+	for i := 0; i < 20; i++ {
+		text := fmt.Sprintf("message %d", i)
+		response, err := client.ExecuteCommand("log", map[string]string{"text": text})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The records a request logged arrive before its response.
+		if logs.count(text, "") != 1 || logs.count("request finished", response.RequestId) != 1 {
+			t.Fatalf("records of request %d had not arrived with its response", i)
+		}
+	}
+
+	// Records logged after the last response are read when stopping the
+	// process, whether reloading or closing.
+	if err := client.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if n := logs.count("plugin exiting", ""); n != 1 {
+		t.Fatalf("got %d exit records after reloading, want 1", n)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := logs.count("plugin exiting", ""); n != 2 {
+		t.Fatalf("got %d exit records after closing, want 2", n)
+	}
+}
@@ -0,0 +1,78 @@
+package host
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+)
+
+// ErrUnsupportedPlatform is returned by ResolveBinary when an installed
+// plugin has no binary for the platform the host runs on.
+var ErrUnsupportedPlatform = errors.New("plugin does not support this platform")
+
+// ResolveBinary returns the binary to launch for the plugin installed in
+// dir. When the manifest lists platforms, the binary is taken from
+// bin/<os>_<arch>/; otherwise it is the file named after the plugin next to
+// the manifest.
+func ResolveBinary(dir string) (string, error) {
+	manifest, err := gsplug.LoadManifest(filepath.Join(dir, gsplug.ManifestFileName))
+	if err != nil {
+		return "", err
+	}
+	name := manifest.Metadata.Name
+	if name == "" {
+		name = filepath.Base(dir)
+	}
+	platform := gsplug.CurrentPlatform
+	binary := BinaryPath(dir, manifest)
+
+	if len(manifest.Platforms) == 0 {
+		if _, err := os.Stat(binary); err != nil {
+			return "", fmt.Errorf("failed to find plugin binary: %w", err)
+		}
+		return binary, nil
+	}
+
+	if !manifest.Supports(platform) {
+		supported := make([]string, len(manifest.Platforms))
+		for i, p := range manifest.Platforms {
+			supported[i] = p.String()
+		}
+		return "", fmt.Errorf("%w: %s is built for %s, not %s",
+			ErrUnsupportedPlatform, name, strings.Join(supported, ", "), platform)
+	}
+	if _, err := os.Stat(binary); err != nil {
+		return "", fmt.Errorf("%w: %s lists %s but its binary is missing: %v",
+			ErrUnsupportedPlatform, name, platform, err)
+	}
+	return binary, nil
+}
+
+// BinaryPath returns where the current platform's binary belongs in the
+// plugin directory dir, whether or not it exists: bin/<os>_<arch>/<name>
+// when manifest lists platforms, and <name> next to the manifest otherwise.
+func BinaryPath(dir string, manifest *gsplug.Manifest) string {
+	name := manifest.Metadata.Name
+	if name == "" {
+		name = filepath.Base(dir)
+	}
+	platform := gsplug.CurrentPlatform
+	if len(manifest.Platforms) == 0 {
+		return filepath.Join(dir, platform.BinaryName(name))
+	}
+	return filepath.Join(dir, gsplug.PlatformsDir, platform.Dir(), platform.BinaryName(name))
+}
+
+// StartInstalled launches the plugin installed in dir with the binary for
+// the current platform.
+func StartInstalled(dir string, opts Options) (*Client, error) {
+	binary, err := ResolveBinary(dir)
+	if err != nil {
+		return nil, err
+	}
+	return Start(binary, opts)
+}
@@ -0,0 +1,150 @@
+package host_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	"github.com/ssotops/gitspace-plugin-sdk/host"
+	"github.com/ssotops/gitspace-plugin-sdk/logger"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+// pluginEnv makes the test binary run testPlugin instead of the tests, so
+// the host can be tested against a real plugin process. Its value is the
+// plugin's mode.
+const pluginEnv = "HOST_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(pluginEnv); mode != "" {
+		runTestPlugin(mode)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runTestPlugin runs testPlugin in mode. In "logs" mode it logs through the
+// SDK logger, which forwards records to hosts that ask for them, and logs
+// once more after the host closed its stdin.
+func runTestPlugin(mode string) {
+	plugin := &testPlugin{broken: mode == "broken"}
+	if mode != "logs" {
+		gsplug.RunPlugin(plugin)
+		return
+	}
+
+	l, err := logger.NewRateLimitedLogger("test-plugin")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	plugin.logger = l
+	gsplug.RunPlugin(plugin)
+	l.Info("plugin exiting")
+	l.Close()
+}
+
+// testPlugin answers commands that exercise the protocol. A broken plugin
+// fails every request that is not a command.
+type testPlugin struct {
+	broken bool
+	logger *logger.RateLimitedLogger
+}
+
+func (p *testPlugin) GetPluginInfo(*pb.PluginInfoRequest) (*pb.PluginInfo, error) {
+	if p.broken {
+		return nil, errors.New("info unavailable")
+	}
+	return &pb.PluginInfo{Name: "test-plugin", Version: "1.0.0"}, nil
+}
+
+func (p *testPlugin) ExecuteCommand(req *pb.CommandRequest) (*pb.CommandResponse, error) {
+	params := req.Parameters
+	switch req.Command {
+	case "echo":
+		return &pb.CommandResponse{Success: true, Result: params["text"]}, nil
+	case "sleep":
+		d, _ := time.ParseDuration(params["duration"])
+		time.Sleep(d)
+		return &pb.CommandResponse{Success: true, Result: "slept"}, nil
+	case "big":
+		size, _ := strconv.Atoi(params["size"])
+		return &pb.CommandResponse{Success: true, Result: strings.Repeat("x", size)}, nil
+	case "repos":
+		repositories, err := gsplug.ListRepositories()
+		if err != nil {
+			return nil, err
+		}
+		return &pb.CommandResponse{Success: true, Result: strings.Join(repositories, ",")}, nil
+	case "secret":
+		secret, err := gsplug.RequestSecret(params["name"])
+		if err != nil {
+			return nil, err
+		}
+		return &pb.CommandResponse{Success: true, Result: secret.Reveal()}, nil
+	case "stream":
+		size, _ := strconv.Atoi(params["size"])
+		w := gsplug.NewChunkWriter(req.RequestId)
+		for i := 0; i < size; i++ {
+			if _, err := w.Write([]byte{byte('a' + i%26)}); err != nil {
+				return nil, err
+			}
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return &pb.CommandResponse{Success: true}, nil
+	case "env":
+		return &pb.CommandResponse{Success: true, Result: os.Getenv(params["name"])}, nil
+	case "secrets":
+		secret, _ := gsplug.GetSecret(params["name"])
+		return &pb.CommandResponse{Success: true, Result: secret.Reveal()}, nil
+	case "log":
+		p.logger.Info(params["text"])
+		return &pb.CommandResponse{Success: true}, nil
+	case "pid":
+		return &pb.CommandResponse{Success: true, Result: strconv.Itoa(os.Getpid())}, nil
+	default:
+		return nil, errors.New("command failed: " + req.Command)
+	}
+}
+
+func (p *testPlugin) GetMenu(*pb.MenuRequest) (*pb.MenuResponse, error) {
+	if p.broken {
+		return nil, errors.New("menu unavailable")
+	}
+	menu, err := json.Marshal([]gsplug.MenuOption{{Label: "Echo", Command: "echo"}})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.MenuResponse{MenuData: menu}, nil
+}
+
+func (p *testPlugin) GetSubscriptions(*pb.SubscriptionRequest) (*pb.SubscriptionResponse, error) {
+	if p.broken {
+		return nil, errors.New("subscriptions unavailable")
+	}
+	return &pb.SubscriptionResponse{Events: []string{string(gsplug.EventRepoSynced)}}, nil
+}
+
+// startPlugin launches testPlugin in mode and closes it when the test ends.
+func startPlugin(t *testing.T, mode string, opts host.Options) *host.Client {
+	t.Helper()
+	opts.Env = append(os.Environ(), pluginEnv+"="+mode)
+	if opts.Stderr == nil {
+		opts.Stderr = io.Discard
+	}
+	client, err := host.Start(os.Args[0], opts)
+	if err != nil {
+		t.Fatalf("failed to start plugin: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
@@ -0,0 +1,130 @@
+package host
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// reloadGrace is how long an old process gets to exit after its stdin is
+// closed before Reload kills it.
+const reloadGrace = 2 * time.Second
+
+// Reload starts a new process from the plugin binary, typically after it was
+// rebuilt, and then stops the old one. The new process is sent the secrets
+// and config updates delivered so far, and its subscriptions are fetched
+// again if GetSubscriptions was called. The Client stays usable throughout:
+// requests wait while the processes are swapped. If the new process fails
+// to start or rejects that state, the old one keeps serving and the error
+// is returned.
+func (c *Client) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	next, err := c.spawn()
+	if err != nil {
+		return err
+	}
+	old := c.process
+	c.process = next
+	if err := c.restore(); err != nil {
+		c.kill()
+		c.process = old
+		return fmt.Errorf("failed to restore the reloaded plugin: %w", err)
+	}
+
+	if !old.stopped {
+		c.stop(old)
+	}
+	return nil
+}
+
+// restore brings the current process up to date with what was delivered to
+// the previous one. Callers must hold c.mu.
+func (c *Client) restore() error {
+	if len(c.delivered.secrets) > 0 {
+		msg, err := c.exchangeWithin(&pb.SecretsRequest{Values: c.delivered.secrets})
+		if err != nil {
+			return fmt.Errorf("failed to send secrets: %w", err)
+		}
+		if response, err := expect[*pb.SecretsResponse](msg); err != nil || !response.Success {
+			return fmt.Errorf("plugin rejected its secrets: %w", responseError(err, response.GetErrorMessage()))
+		}
+	}
+	if len(c.delivered.config) > 0 {
+		msg, err := c.exchangeWithin(&pb.UpdateConfigRequest{Values: c.delivered.config})
+		if err != nil {
+			return fmt.Errorf("failed to send config: %w", err)
+		}
+		if response, err := expect[*pb.UpdateConfigResponse](msg); err != nil || !response.Success {
+			return fmt.Errorf("plugin rejected its config: %w", responseError(err, response.GetErrorMessage()))
+		}
+	}
+	if c.delivered.subscribed {
+		msg, err := c.exchangeWithin(&pb.SubscriptionRequest{})
+		if err != nil {
+			return fmt.Errorf("failed to get subscriptions: %w", err)
+		}
+		response, err := expect[*pb.SubscriptionResponse](msg)
+		if err != nil || response.ErrorMessage != "" {
+			return fmt.Errorf("failed to get subscriptions: %w", responseError(err, response.GetErrorMessage()))
+		}
+		c.delivered.subscriptions = subscriptionEvents(response)
+	}
+	return nil
+}
+
+// exchangeWithin is exchange bounded by the request timeout. A process that
+// does not answer in time is killed.
+func (c *Client) exchangeWithin(req proto.Message) (proto.Message, error) {
+	timeout := c.requestTimeout()
+	if timeout < 0 {
+		return c.exchange(req, nil)
+	}
+
+	done := make(chan exchangeResult, 1)
+	go func() {
+		msg, err := c.exchange(req, nil)
+		done <- exchangeResult{msg, err}
+	}()
+	select {
+	case result := <-done:
+		return result.msg, result.err
+	case <-time.After(timeout):
+		c.cmd.Process.Kill()
+		<-done
+		return nil, fmt.Errorf("no response within %s", timeout)
+	}
+}
+
+// responseError returns err, or the error message of a failed response.
+func responseError(err error, message string) error {
+	if err != nil {
+		return err
+	}
+	return errors.New(message)
+}
+
+// stop closes p's stdin, passes the log records it sends while exiting to
+// Options.Logs and kills it if it does not exit within reloadGrace.
+func (c *Client) stop(p process) {
+	p.stdin.Close()
+	drained := make(chan struct{})
+	go func() {
+		c.drain(p)
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(reloadGrace):
+		p.cmd.Process.Kill()
+		<-drained
+	}
+	<-p.waitErr
+	if p.cgroup != nil {
+		p.cgroup.remove()
+	}
+}
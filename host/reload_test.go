@@ -0,0 +1,53 @@
+package host_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	"github.com/ssotops/gitspace-plugin-sdk/host"
+)
+
+func TestReloadRestoresState(t *testing.T) {
+	tests := []struct {
+		name    string
+		restart func(*host.Client) error
+	}{
+		{"reload", (*host.Client).Reload},
+		{"restart", (*host.Client).Restart},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := startPlugin(t, "ok", host.Options{})
+			if err := client.SetSecrets(map[string]string{"token": "s3cret"}); err != nil {
+				t.Fatal(err)
+			}
+			if client.Subscriptions() != nil {
+				t.Fatal("subscriptions known before GetSubscriptions")
+			}
+			if _, err := client.GetSubscriptions(); err != nil {
+				t.Fatal(err)
+			}
+			before, err := client.ExecuteCommand("pid", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tt.restart(client); err != nil {
+				t.Fatal(err)
+			}
+
+			after, err := client.ExecuteCommand("pid", nil)
+			if err != nil || after.Result == before.Result {
+				t.Fatalf("plugin still runs as pid %s (%v)", after.GetResult(), err)
+			}
+			response, err := client.ExecuteCommand("secrets", map[string]string{"name": "token"})
+			if err != nil || response.Result != "s3cret" {
+				t.Fatalf("new process has secret %q (%v)", response.GetResult(), err)
+			}
+			if events := client.Subscriptions(); !reflect.DeepEqual(events, []gsplug.EventType{gsplug.EventRepoSynced}) {
+				t.Fatalf("subscriptions after %s: %v", tt.name, events)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package host
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+)
+
+// SandboxPolicy reduces the privileges of a launched plugin. Namespaces,
+// rlimits, filesystem rules and seccomp are only applied on Linux; elsewhere
+// only the working directory and environment restrictions take effect.
+//
+// On Linux the plugin is started through a shim, the host executable run
+// again, which applies the rlimits, filesystem rules and seccomp filter to
+// itself before it executes the plugin, so the plugin never runs without
+// them.
+type SandboxPolicy struct {
+	// WorkDir is created if needed and used as the plugin's working directory.
+	WorkDir string
+	// AllowEnv lists the environment variables passed through to the plugin.
+	// Everything else is cleared.
+	AllowEnv []string
+	// ExtraEnv is appended to the filtered environment, as KEY=value pairs.
+	ExtraEnv []string
+
+	// CPUSeconds, MemoryBytes and OpenFiles set RLIMIT_CPU, RLIMIT_DATA and
+	// RLIMIT_NOFILE. RLIMIT_DATA is used instead of RLIMIT_AS because the Go
+	// runtime reserves far more address space than it uses. Zero leaves the
+	// limit unchanged.
+	CPUSeconds  uint64
+	MemoryBytes uint64
+	OpenFiles   uint64
+
+	// NewUserNamespace runs the plugin in a new user namespace, mapping the
+	// current user to itself. The mount and network namespaces require it
+	// when the host is unprivileged.
+	NewUserNamespace bool
+	// NewMountNamespace gives the plugin a private, empty /tmp.
+	NewMountNamespace   bool
+	NewNetworkNamespace bool
+
+	// RestrictFilesystem limits the plugin to the paths in Filesystem plus
+	// what every program needs: the system directories and the plugin's
+	// own directory are readable and executable, and WorkDir, the
+	// temporary directory and /dev are writable. It uses Landlock, which
+	// needs Linux 5.13 or later.
+	RestrictFilesystem bool
+	Filesystem         []gsplug.FilesystemPermission
+	// Seccomp installs a seccomp filter failing, with EPERM, the syscalls
+	// plugins have no use for: ptrace, mount, namespaces, bpf, kernel
+	// modules and keyrings among others. It needs linux/amd64 or
+	// linux/arm64.
+	Seccomp bool
+}
+
+// DefaultAllowEnv is the environment kept by SandboxPolicyFor. HOME stays so
+// the SDK can find ~/.ssot/gitspace; credentials such as SSH_AUTH_SOCK or
+// GITHUB_TOKEN do not.
+var DefaultAllowEnv = []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TERM", "TMPDIR"}
+
+// SandboxPolicyFor derives a policy from the permissions a plugin declared:
+// it runs in its data directory with a minimal environment and a seccomp
+// filter, may only touch the paths it declared besides its own data, log
+// and config directories, and gets its own network namespace and /tmp
+// unless it declared network access.
+func SandboxPolicyFor(pluginName string, permissions gsplug.Permissions) (*SandboxPolicy, error) {
+	workDir, err := gsplug.GetPluginDataDir(pluginName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plugin data directory: %w", err)
+	}
+	logDir, err := gsplug.GetPluginLogDir(pluginName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plugin log directory: %w", err)
+	}
+	configPath, err := gsplug.GetPluginConfigPath(pluginName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plugin config path: %w", err)
+	}
+
+	filesystem := append([]gsplug.FilesystemPermission{
+		{Path: logDir, Access: gsplug.AccessReadWrite},
+		{Path: filepath.Dir(configPath), Access: gsplug.AccessReadWrite},
+	}, permissions.Filesystem...)
+
+	isolateNetwork := len(permissions.Network) == 0
+	return &SandboxPolicy{
+		WorkDir:             workDir,
+		AllowEnv:            DefaultAllowEnv,
+		OpenFiles:           256,
+		NewUserNamespace:    isolateNetwork,
+		NewMountNamespace:   isolateNetwork,
+		NewNetworkNamespace: isolateNetwork,
+		RestrictFilesystem:  true,
+		Filesystem:          filesystem,
+		Seccomp:             true,
+	}, nil
+}
+
+func (p *SandboxPolicy) usesNamespaces() bool {
+	return p.NewUserNamespace || p.NewMountNamespace || p.NewNetworkNamespace
+}
+
+// environ filters base down to the allowed variables plus ExtraEnv.
+func (p *SandboxPolicy) environ(base []string) []string {
+	allowed := make(map[string]bool, len(p.AllowEnv))
+	for _, name := range p.AllowEnv {
+		allowed[name] = true
+	}
+
+	env := make([]string, 0, len(p.AllowEnv)+len(p.ExtraEnv))
+	for _, kv := range base {
+		name, _, _ := strings.Cut(kv, "=")
+		if allowed[name] {
+			env = append(env, kv)
+		}
+	}
+	return append(env, p.ExtraEnv...)
+}
+
+// prepare creates the working directory and the writable directories, so
+// the plugin can use them once it may no longer create them.
+func (p *SandboxPolicy) prepare() error {
+	if p.WorkDir != "" {
+		if err := os.MkdirAll(p.WorkDir, 0755); err != nil {
+			return fmt.Errorf("failed to create sandbox working directory: %w", err)
+		}
+	}
+	if !p.RestrictFilesystem {
+		return nil
+	}
+	for _, permission := range p.Filesystem {
+		if permission.Access != gsplug.AccessReadWrite {
+			continue
+		}
+		if err := os.MkdirAll(expandHome(permission.Path), 0755); err != nil {
+			return fmt.Errorf("failed to create sandbox directory: %w", err)
+		}
+	}
+	return nil
+}
+
+// pathAccess is what a filesystem rule allows beneath its path.
+type pathAccess string
+
+const (
+	pathRead  pathAccess = "read"
+	pathExec  pathAccess = "exec"
+	pathWrite pathAccess = "write"
+)
+
+type pathRule struct {
+	Path   string     `json:"path"`
+	Access pathAccess `json:"access"`
+}
+
+// systemDirs are readable and executable by every plugin with a restricted
+// filesystem. /proc is left out: it would expose the environment of the
+// user's other processes.
+var systemDirs = []string{"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/etc"}
+
+// pathRules lists the filesystem rules for a plugin started from binary.
+func (p *SandboxPolicy) pathRules(binary string) []pathRule {
+	var rules []pathRule
+	for _, dir := range systemDirs {
+		rules = append(rules, pathRule{dir, pathExec})
+	}
+	rules = append(rules,
+		pathRule{filepath.Dir(binary), pathExec},
+		pathRule{"/dev", pathWrite},
+		pathRule{os.TempDir(), pathWrite},
+	)
+	if p.WorkDir != "" {
+		rules = append(rules, pathRule{p.WorkDir, pathWrite})
+	}
+	for _, permission := range p.Filesystem {
+		access := pathRead
+		if permission.Access == gsplug.AccessReadWrite {
+			access = pathWrite
+		}
+		rules = append(rules, pathRule{expandHome(permission.Path), access})
+	}
+	return rules
+}
+
+// expandHome replaces a leading ~ with the user's home directory.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~")
+	if !ok || (rest != "" && rest[0] != '/') {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
@@ -0,0 +1,107 @@
+//go:build linux
+
+package host
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func applySandbox(cmd *exec.Cmd, policy *SandboxPolicy, namespaces bool) {
+	attr := &syscall.SysProcAttr{Pdeathsig: syscall.SIGKILL}
+	if namespaces {
+		if policy.NewUserNamespace {
+			attr.Cloneflags |= syscall.CLONE_NEWUSER
+			attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+			attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+		}
+		if policy.NewMountNamespace {
+			attr.Cloneflags |= syscall.CLONE_NEWNS
+		}
+		if policy.NewNetworkNamespace {
+			attr.Cloneflags |= syscall.CLONE_NEWNET
+		}
+	}
+	cmd.SysProcAttr = attr
+}
+
+// namespaceUnavailable reports whether a start error means the kernel or
+// container refused to create the requested namespaces.
+func namespaceUnavailable(err error) bool {
+	return errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EINVAL) ||
+		errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EACCES)
+}
+
+// confine arranges for cmd to start through the sandbox shim, which applies
+// the policy's rlimits, filesystem rules and seccomp filter, and memoryBytes
+// as RLIMIT_DATA when non-zero, before executing the plugin. policy may be
+// nil. It returns warnings for restrictions the system cannot apply.
+func confine(cmd *exec.Cmd, policy *SandboxPolicy, memoryBytes uint64) ([]string, error) {
+	binary := cmd.Path
+	if !filepath.IsAbs(binary) {
+		binary = filepath.Join(cmd.Dir, binary)
+		if abs, err := filepath.Abs(binary); err == nil {
+			binary = abs
+		}
+	}
+
+	var warnings []string
+	config := shimConfig{Binary: binary}
+	addRlimit := func(resource int, value uint64) {
+		if value > 0 {
+			config.Rlimits = append(config.Rlimits, shimRlimit{resource, value})
+		}
+	}
+	if policy != nil {
+		addRlimit(unix.RLIMIT_CPU, policy.CPUSeconds)
+		addRlimit(unix.RLIMIT_DATA, policy.MemoryBytes)
+		addRlimit(unix.RLIMIT_NOFILE, policy.OpenFiles)
+		config.PrivateTmp = policy.NewMountNamespace &&
+			cmd.SysProcAttr != nil && cmd.SysProcAttr.Cloneflags&syscall.CLONE_NEWNS != 0
+
+		if policy.RestrictFilesystem {
+			if abi := landlockABI(); abi < 1 {
+				warnings = append(warnings, "landlock unavailable, filesystem not restricted")
+			} else {
+				config.Paths = policy.pathRules(binary)
+				config.RestrictFilesystem = true
+			}
+		}
+		if policy.Seccomp {
+			if err := seccompAvailable(); err != nil {
+				warnings = append(warnings, fmt.Sprintf("seccomp unavailable: %v", err))
+			} else {
+				config.Seccomp = true
+			}
+		}
+	}
+	if policy == nil || policy.MemoryBytes == 0 || (memoryBytes > 0 && memoryBytes < policy.MemoryBytes) {
+		addRlimit(unix.RLIMIT_DATA, memoryBytes)
+	}
+
+	if len(config.Rlimits) == 0 && !config.PrivateTmp && !config.RestrictFilesystem && !config.Seccomp {
+		return warnings, nil
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the host executable for the sandbox shim: %w", err)
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode sandbox shim config: %w", err)
+	}
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, shimEnv+"="+string(data))
+	cmd.Path = self
+	return warnings, nil
+}
@@ -0,0 +1,32 @@
+//go:build !linux
+
+package host
+
+import "os/exec"
+
+func applySandbox(cmd *exec.Cmd, policy *SandboxPolicy, namespaces bool) {}
+
+func namespaceUnavailable(err error) bool {
+	return false
+}
+
+// confine reports the restrictions that are only supported on linux.
+func confine(cmd *exec.Cmd, policy *SandboxPolicy, memoryBytes uint64) ([]string, error) {
+	var warnings []string
+	if memoryBytes > 0 {
+		warnings = append(warnings, "memory limits are only supported on linux")
+	}
+	if policy == nil {
+		return warnings, nil
+	}
+	if policy.CPUSeconds > 0 || policy.MemoryBytes > 0 || policy.OpenFiles > 0 {
+		warnings = append(warnings, "rlimits are only supported on linux")
+	}
+	if policy.RestrictFilesystem {
+		warnings = append(warnings, "filesystem restrictions are only supported on linux")
+	}
+	if policy.Seccomp {
+		warnings = append(warnings, "seccomp is only supported on linux")
+	}
+	return warnings, nil
+}
@@ -0,0 +1,70 @@
+//go:build linux
+
+package host
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// offsets into struct seccomp_data
+const (
+	seccompDataNr   = 0
+	seccompDataArch = 4
+)
+
+// seccompAvailable reports why the filter cannot be installed, if it cannot.
+func seccompAvailable() error {
+	if seccompArch == 0 {
+		return errors.New("unsupported architecture")
+	}
+	if _, err := unix.PrctlRetInt(unix.PR_GET_SECCOMP, 0, 0, 0, 0); err != nil {
+		return fmt.Errorf("not supported by the kernel: %w", err)
+	}
+	return nil
+}
+
+// seccompFilter fails the denied syscalls with EPERM and kills the process
+// when it makes a syscall for another architecture.
+func seccompFilter() []unix.SockFilter {
+	stmt := func(code uint16, k uint32) unix.SockFilter {
+		return unix.SockFilter{Code: code, K: k}
+	}
+	jump := func(code uint16, k uint32, jt, jf uint8) unix.SockFilter {
+		return unix.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+	}
+	deny := stmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_ERRNO|uint32(unix.EPERM))
+
+	filter := []unix.SockFilter{
+		stmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataArch),
+		jump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, seccompArch, 1, 0),
+		stmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_KILL_PROCESS),
+		stmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataNr),
+	}
+	if seccompSyscallLimit > 0 {
+		filter = append(filter,
+			jump(unix.BPF_JMP|unix.BPF_JGE|unix.BPF_K, seccompSyscallLimit, 0, 1),
+			deny)
+	}
+	for _, nr := range deniedSyscalls {
+		filter = append(filter,
+			jump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, uint32(nr), 0, 1),
+			deny)
+	}
+	return append(filter, stmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_ALLOW))
+}
+
+// installSeccomp installs the filter on the calling thread. The caller must
+// have set no_new_privs.
+func installSeccomp() error {
+	filter := seccompFilter()
+	program := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	if _, _, errno := unix.Syscall(unix.SYS_SECCOMP, unix.SECCOMP_SET_MODE_FILTER, 0,
+		uintptr(unsafe.Pointer(&program))); errno != 0 {
+		return fmt.Errorf("failed to install seccomp filter: %w", errno)
+	}
+	return nil
+}
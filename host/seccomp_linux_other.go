@@ -0,0 +1,10 @@
+//go:build linux && !amd64 && !arm64
+
+package host
+
+// The filter's syscall numbers are only listed for amd64 and arm64.
+var (
+	seccompArch         uint32
+	seccompSyscallLimit uint32
+	deniedSyscalls      []uintptr
+)
@@ -0,0 +1,57 @@
+//go:build linux && (amd64 || arm64)
+
+package host
+
+import (
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+var seccompArch = map[string]uint32{
+	"amd64": unix.AUDIT_ARCH_X86_64,
+	"arm64": unix.AUDIT_ARCH_AARCH64,
+}[runtime.GOARCH]
+
+// seccompSyscallLimit denies syscall numbers from this one up. On amd64
+// they belong to the x32 ABI, which shares the architecture value.
+var seccompSyscallLimit = map[string]uint32{
+	"amd64": 0x40000000,
+}[runtime.GOARCH]
+
+// deniedSyscalls debug other processes, change the mount table or
+// namespaces, load code into the kernel, reach kernel keyrings or change
+// system-wide state.
+var deniedSyscalls = []uintptr{
+	unix.SYS_PTRACE,
+	unix.SYS_PROCESS_VM_READV,
+	unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_MOUNT,
+	unix.SYS_UMOUNT2,
+	unix.SYS_PIVOT_ROOT,
+	unix.SYS_FSOPEN,
+	unix.SYS_FSMOUNT,
+	unix.SYS_MOVE_MOUNT,
+	unix.SYS_OPEN_TREE,
+	unix.SYS_UNSHARE,
+	unix.SYS_SETNS,
+	unix.SYS_BPF,
+	unix.SYS_PERF_EVENT_OPEN,
+	unix.SYS_USERFAULTFD,
+	unix.SYS_INIT_MODULE,
+	unix.SYS_FINIT_MODULE,
+	unix.SYS_DELETE_MODULE,
+	unix.SYS_KEXEC_LOAD,
+	unix.SYS_KEXEC_FILE_LOAD,
+	unix.SYS_KEYCTL,
+	unix.SYS_ADD_KEY,
+	unix.SYS_REQUEST_KEY,
+	unix.SYS_OPEN_BY_HANDLE_AT,
+	unix.SYS_REBOOT,
+	unix.SYS_SWAPON,
+	unix.SYS_SWAPOFF,
+	unix.SYS_ACCT,
+	unix.SYS_QUOTACTL,
+	unix.SYS_SETTIMEOFDAY,
+	unix.SYS_CLOCK_SETTIME,
+}
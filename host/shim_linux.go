@@ -0,0 +1,123 @@
+//go:build linux
+
+package host
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// shimEnv carries the shim's configuration. A host executable started with
+// it set runs as the sandbox shim instead of its main function.
+const shimEnv = "GITSPACE_SANDBOX_SHIM"
+
+type shimConfig struct {
+	Binary             string       `json:"binary"`
+	Rlimits            []shimRlimit `json:"rlimits,omitempty"`
+	PrivateTmp         bool         `json:"private_tmp,omitempty"`
+	RestrictFilesystem bool         `json:"restrict_filesystem,omitempty"`
+	Paths              []pathRule   `json:"paths,omitempty"`
+	Seccomp            bool         `json:"seccomp,omitempty"`
+}
+
+type shimRlimit struct {
+	Resource int    `json:"resource"`
+	Value    uint64 `json:"value"`
+}
+
+func init() {
+	if config, ok := os.LookupEnv(shimEnv); ok {
+		runShim(config)
+	}
+}
+
+// runShim confines the current process and replaces it with the plugin.
+// It runs before the host's main function and only returns by exiting.
+func runShim(data string) {
+	// Landlock and seccomp apply to the calling thread, which is the one
+	// that executes the plugin.
+	runtime.LockOSThread()
+	os.Unsetenv(shimEnv)
+
+	var config shimConfig
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		shimFail(fmt.Errorf("failed to decode config: %w", err))
+	}
+	binary, err := config.apply()
+	if err != nil {
+		shimFail(err)
+	}
+	err = syscall.Exec(binary, os.Args, os.Environ())
+	shimFail(fmt.Errorf("failed to start plugin %s: %w", config.Binary, err))
+}
+
+func shimFail(err error) {
+	fmt.Fprintf(os.Stderr, "gitspace sandbox: %v\n", err)
+	os.Exit(126)
+}
+
+// apply confines the calling thread and returns the path to execute the
+// plugin from. When a private /tmp hides the plugin's binary, that is the
+// binary's descriptor in /proc/self/fd, opened before the mount.
+func (c shimConfig) apply() (string, error) {
+	binary := c.Binary
+	var ruleset *landlockRuleset
+	if c.RestrictFilesystem {
+		var err error
+		if ruleset, err = newLandlockRuleset(); err != nil {
+			return "", err
+		}
+		if err := ruleset.allow(c.Paths...); err != nil {
+			return "", err
+		}
+	}
+
+	if c.PrivateTmp {
+		fd, err := unix.Open(c.Binary, unix.O_PATH|unix.O_CLOEXEC, 0)
+		if err != nil {
+			return "", fmt.Errorf("failed to open plugin %s: %w", c.Binary, err)
+		}
+		binary = fmt.Sprintf("/proc/self/fd/%d", fd)
+
+		if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+			return "", fmt.Errorf("failed to make mounts private: %w", err)
+		}
+		if err := unix.Mount("tmpfs", "/tmp", "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=1777"); err != nil {
+			return "", fmt.Errorf("failed to mount private /tmp: %w", err)
+		}
+		if ruleset != nil {
+			if err := ruleset.allow(pathRule{"/tmp", pathWrite}); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	for _, limit := range c.Rlimits {
+		rlimit := &unix.Rlimit{Cur: limit.Value, Max: limit.Value}
+		if err := unix.Setrlimit(limit.Resource, rlimit); err != nil {
+			return "", fmt.Errorf("failed to set rlimit %d: %w", limit.Resource, err)
+		}
+	}
+
+	if c.RestrictFilesystem || c.Seccomp {
+		if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+			return "", fmt.Errorf("failed to set no_new_privs: %w", err)
+		}
+	}
+	if ruleset != nil {
+		if err := ruleset.enforce(); err != nil {
+			return "", err
+		}
+	}
+	if c.Seccomp {
+		if err := installSeccomp(); err != nil {
+			return "", err
+		}
+	}
+	return binary, nil
+}
@@ -0,0 +1,149 @@
+package host
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+// ErrChecksumMismatch is returned when streamed data does not match the
+// checksum sent with the final chunk.
+var ErrChecksumMismatch = errors.New("chunk checksum mismatch")
+
+// chunkSink writes a request's chunks to w in order and verifies the final
+// checksum. Chunks are acknowledged only after w accepted them, so a slow
+// writer holds back the plugin.
+type chunkSink struct {
+	requestID string
+	w         io.Writer
+	hash      hash.Hash
+	next      uint64
+	final     bool
+	err       error
+}
+
+func newChunkSink(requestID string, w io.Writer) *chunkSink {
+	return &chunkSink{
+		requestID: requestID,
+		w:         w,
+		hash:      sha256.New(),
+	}
+}
+
+func (s *chunkSink) write(chunk *pb.Chunk) {
+	if s == nil || s.err != nil {
+		return
+	}
+
+	switch {
+	case chunk.RequestId != s.requestID:
+		s.err = fmt.Errorf("chunk for request %q while running %q", chunk.RequestId, s.requestID)
+		return
+	case chunk.Sequence != s.next:
+		s.err = fmt.Errorf("chunk %d received, expected %d", chunk.Sequence, s.next)
+		return
+	}
+	s.next++
+
+	s.hash.Write(chunk.Data)
+	if _, err := s.w.Write(chunk.Data); err != nil {
+		s.err = fmt.Errorf("failed to write chunk: %w", err)
+		return
+	}
+
+	if chunk.Final {
+		s.final = true
+		if sum := gsplug.ChecksumChunks(s.hash); sum != chunk.Checksum {
+			s.err = fmt.Errorf("%w: got %s, plugin sent %s", ErrChecksumMismatch, sum, chunk.Checksum)
+		}
+	}
+}
+
+// result returns the first error seen while streaming, or an error if the
+// plugin started streaming but never sent the final chunk.
+func (s *chunkSink) result() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.next > 0 && !s.final {
+		return errors.New("plugin did not finish streaming")
+	}
+	return nil
+}
+
+// ExecuteCommandStream runs a command and writes the data the plugin streams
+// for it to w. The plugin waits for each chunk to be written before sending
+// more than gsplug.ChunkWindow further chunks.
+func (c *Client) ExecuteCommandStream(command string, parameters map[string]string, w io.Writer) (*pb.CommandResponse, error) {
+	req := &pb.CommandRequest{
+		Command:    command,
+		Parameters: parameters,
+		RequestId:  gsplug.NewRequestID(),
+	}
+	sink := newChunkSink(req.RequestId, w)
+	done := c.logCommand(req)
+
+	response, err := c.executeCommandStream(req, sink)
+	done(response, err)
+	return response, err
+}
+
+func (c *Client) executeCommandStream(req *pb.CommandRequest, sink *chunkSink) (*pb.CommandResponse, error) {
+	msg, err := c.roundTripStream(req, sink)
+	if err != nil {
+		return nil, err
+	}
+	response, err := expect[*pb.CommandResponse](msg)
+	if err != nil {
+		return nil, err
+	}
+	return response, sink.result()
+}
+
+// CommandStream reads the data a plugin streams for a command while the
+// command runs.
+type CommandStream struct {
+	reader   *io.PipeReader
+	done     chan struct{}
+	response *pb.CommandResponse
+	err      error
+}
+
+// StreamCommand starts a command and returns a stream of the data the plugin
+// sends for it. Read the stream to the end, or Close it, before calling other
+// methods on the client.
+func (c *Client) StreamCommand(command string, parameters map[string]string) *CommandStream {
+	reader, writer := io.Pipe()
+	stream := &CommandStream{
+		reader: reader,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(stream.done)
+		stream.response, stream.err = c.ExecuteCommandStream(command, parameters, writer)
+		writer.CloseWithError(stream.err)
+	}()
+
+	return stream
+}
+
+func (s *CommandStream) Read(p []byte) (int, error) {
+	return s.reader.Read(p)
+}
+
+// Close stops reading. Remaining chunks are acknowledged and dropped.
+func (s *CommandStream) Close() error {
+	return s.reader.Close()
+}
+
+// Response waits for the command to finish and returns its response.
+func (s *CommandStream) Response() (*pb.CommandResponse, error) {
+	<-s.done
+	return s.response, s.err
+}
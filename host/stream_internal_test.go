@@ -0,0 +1,67 @@
+package host
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestChunkSink(t *testing.T) {
+	h := sha256.New()
+	h.Write([]byte("hello world"))
+	checksum := gsplug.ChecksumChunks(h)
+	chunk := func(id string, sequence uint64, data string, final bool, checksum string) *pb.Chunk {
+		return &pb.Chunk{RequestId: id, Sequence: sequence, Data: []byte(data), Final: final, Checksum: checksum}
+	}
+
+	tests := []struct {
+		name   string
+		chunks []*pb.Chunk
+		wrote  string
+		error  string
+	}{
+		{"complete", []*pb.Chunk{chunk("r", 0, "hello ", false, ""), chunk("r", 1, "world", true, checksum)}, "hello world", ""},
+		{"nothing streamed", nil, "", ""},
+		{"checksum mismatch", []*pb.Chunk{chunk("r", 0, "hello ", false, ""), chunk("r", 1, "w0rld", true, checksum)}, "", ErrChecksumMismatch.Error()},
+		{"out of order", []*pb.Chunk{chunk("r", 1, "world", true, checksum)}, "", "chunk 1 received, expected 0"},
+		{"other request", []*pb.Chunk{chunk("other", 0, "hello world", true, checksum)}, "", `chunk for request "other"`},
+		{"no final chunk", []*pb.Chunk{chunk("r", 0, "hello ", false, "")}, "", "did not finish streaming"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			sink := newChunkSink("r", &out)
+			for _, c := range tt.chunks {
+				sink.write(c)
+			}
+
+			err := sink.result()
+			if tt.error == "" {
+				if err != nil || out.String() != tt.wrote {
+					t.Fatalf("wrote %q, err %v", out.String(), err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Fatalf("got error %v, want %q", err, tt.error)
+			}
+		})
+	}
+
+	sink := newChunkSink("r", failingWriter{})
+	sink.write(chunk("r", 0, "hello world", true, checksum))
+	if err := sink.result(); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("writer error reported as %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package host_test
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	"github.com/ssotops/gitspace-plugin-sdk/host"
+)
+
+// streamed returns the data testPlugin's stream command sends for size.
+func streamed(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte('a' + i%26)
+	}
+	return data
+}
+
+func TestExecuteCommandStream(t *testing.T) {
+	for _, size := range []int{0, 10, gsplug.ChunkSize, 5*gsplug.ChunkSize + 7} {
+		t.Run(strconv.Itoa(size), func(t *testing.T) {
+			client := startPlugin(t, "ok", host.Options{Framing: gsplug.FramingChecked})
+
+			var out bytes.Buffer
+			response, err := client.ExecuteCommandStream("stream", map[string]string{"size": strconv.Itoa(size)}, &out)
+			if err != nil || !response.Success {
+				t.Fatalf("ExecuteCommandStream = %v, %v", response, err)
+			}
+			if !bytes.Equal(out.Bytes(), streamed(size)) {
+				t.Fatalf("received %d bytes, want %d", out.Len(), size)
+			}
+		})
+	}
+}
+
+func TestStreamCommand(t *testing.T) {
+	client := startPlugin(t, "ok", host.Options{})
+	size := 3*gsplug.ChunkSize + 1
+
+	stream := client.StreamCommand("stream", map[string]string{"size": strconv.Itoa(size)})
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response, err := stream.Response(); err != nil || !response.Success {
+		t.Fatalf("Response = %v, %v", response, err)
+	}
+	if !bytes.Equal(data, streamed(size)) {
+		t.Fatalf("read %d bytes, want %d", len(data), size)
+	}
+
+	// Closing a stream early drops the rest without breaking the client.
+	stream = client.StreamCommand("stream", map[string]string{"size": strconv.Itoa(size)})
+	if _, err := stream.Read(make([]byte, 10)); err != nil {
+		t.Fatal(err)
+	}
+	stream.Close()
+	stream.Response()
+	if response, err := client.ExecuteCommand("echo", map[string]string{"text": "after"}); err != nil || response.Result != "after" {
+		t.Fatalf("request after a closed stream: %v, %v", response, err)
+	}
+}
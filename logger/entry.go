@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Entry is a log record in the SDK's structured form, as written by the
+// JSON and logfmt file formats, forwarded to the host and returned by
+// Reader.
+type Entry struct {
+	Time      time.Time
+	Level     log.Level
+	Message   string
+	Plugin    string
+	RequestID string
+	Caller    string
+	// Attrs are the remaining attributes in order, with group members
+	// named "group.key".
+	Attrs []Attr
+}
+
+// Attr is a key and its value as text.
+type Attr struct {
+	Key   string
+	Value string
+}
+
+// Attr returns the value of the first attribute named key.
+func (e Entry) Attr(key string) (string, bool) {
+	for _, attr := range e.Attrs {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// entryHandler is a slog.Handler that turns records into entries and
+// passes them to emit. A top-level request_id attribute fills RequestID
+// instead of Attrs.
+type entryHandler struct {
+	plugin    string
+	emit      func(Entry) error
+	attrs     []Attr
+	requestID string
+	prefix    string
+}
+
+func (h *entryHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+func (h *entryHandler) Handle(ctx context.Context, record slog.Record) error {
+	entry := Entry{
+		Time:      record.Time,
+		Level:     levelOf(record.Level),
+		Message:   record.Message,
+		Plugin:    h.plugin,
+		RequestID: h.requestID,
+		Caller:    callerOf(record.PC),
+		Attrs:     append([]Attr(nil), h.attrs...),
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		entry.Attrs = appendAttr(entry.Attrs, &entry.RequestID, h.prefix, attr)
+		return true
+	})
+	return h.emit(entry)
+}
+
+func (h *entryHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]Attr(nil), h.attrs...)
+	for _, attr := range attrs {
+		clone.attrs = appendAttr(clone.attrs, &clone.requestID, h.prefix, attr)
+	}
+	return &clone
+}
+
+func (h *entryHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+func appendAttr(attrs []Attr, requestID *string, prefix string, attr slog.Attr) []Attr {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, member := range value.Group() {
+			attrs = appendAttr(attrs, requestID, prefix, member)
+		}
+		return attrs
+	}
+	if prefix == "" && attr.Key == "request_id" {
+		*requestID = value.String()
+		return attrs
+	}
+	return append(attrs, Attr{Key: prefix + attr.Key, Value: value.String()})
+}
+
+// levelOf rounds a slog level down to the nearest charmbracelet/log level.
+func levelOf(level slog.Level) log.Level {
+	switch {
+	case level >= slog.Level(log.FatalLevel):
+		return log.FatalLevel
+	case level >= slog.LevelError:
+		return log.ErrorLevel
+	case level >= slog.LevelWarn:
+		return log.WarnLevel
+	case level >= slog.LevelInfo:
+		return log.InfoLevel
+	default:
+		return log.DebugLevel
+	}
+}
+
+// callerOf formats the source location of pc as "dir/file.go:line", the
+// form charmbracelet/log prints.
+func callerOf(pc uintptr) string {
+	if pc == 0 {
+		return ""
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.File == "" {
+		return ""
+	}
+	dir, file := filepath.Split(frame.File)
+	return fmt.Sprintf("%s:%d", filepath.Join(filepath.Base(dir), file), frame.Line)
+}
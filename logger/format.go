@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/go-logfmt/logfmt"
+)
+
+// Format is the layout of a RateLimitedLogger's log file.
+type Format string
+
+const (
+	// FormatText is charmbracelet/log's human-readable text.
+	FormatText Format = "text"
+	// FormatJSON writes one JSON object per line.
+	FormatJSON Format = "json"
+	// FormatLogfmt writes one logfmt line per record.
+	FormatLogfmt Format = "logfmt"
+)
+
+// Field names used by the JSON and logfmt formats, in the order they are
+// written. Attributes follow; one named like a field is prefixed with
+// "attr_".
+const (
+	FieldTime      = "ts"
+	FieldLevel     = "level"
+	FieldMessage   = "msg"
+	FieldPlugin    = "plugin"
+	FieldRequestID = "request_id"
+	FieldCaller    = "caller"
+)
+
+var fieldNames = map[string]bool{
+	FieldTime: true, FieldLevel: true, FieldMessage: true,
+	FieldPlugin: true, FieldRequestID: true, FieldCaller: true,
+}
+
+func (f Format) valid() bool {
+	switch f {
+	case "", FormatText, FormatJSON, FormatLogfmt:
+		return true
+	}
+	return false
+}
+
+// newFileSink returns the handler writing the log file in format.
+func newFileSink(w io.Writer, format Format, plugin string) slog.Handler {
+	if format == "" || format == FormatText {
+		return log.NewWithOptions(w, log.Options{
+			ReportCaller:    true,
+			ReportTimestamp: true,
+			Level:           log.DebugLevel,
+		})
+	}
+	return &entryHandler{plugin: plugin, emit: func(entry Entry) error {
+		line, err := EncodeEntry(format, entry)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(line)
+		return err
+	}}
+}
+
+// entryFields returns the entry as ordered key/value pairs.
+func entryFields(entry Entry) []Attr {
+	fields := []Attr{
+		{FieldTime, entry.Time.Format(time.RFC3339Nano)},
+		{FieldLevel, entry.Level.String()},
+		{FieldMessage, entry.Message},
+	}
+	if entry.Plugin != "" {
+		fields = append(fields, Attr{FieldPlugin, entry.Plugin})
+	}
+	if entry.RequestID != "" {
+		fields = append(fields, Attr{FieldRequestID, entry.RequestID})
+	}
+	if entry.Caller != "" {
+		fields = append(fields, Attr{FieldCaller, entry.Caller})
+	}
+	for _, attr := range entry.Attrs {
+		if fieldNames[attr.Key] {
+			attr.Key = "attr_" + attr.Key
+		}
+		fields = append(fields, attr)
+	}
+	return fields
+}
+
+// EncodeEntry renders entry as a single line in FormatJSON or FormatLogfmt,
+// newline included.
+func EncodeEntry(format Format, entry Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	fields := entryFields(entry)
+	if format == FormatLogfmt {
+		enc := logfmt.NewEncoder(&buf)
+		for _, field := range fields {
+			if err := enc.EncodeKeyval(field.Key, field.Value); err != nil {
+				return nil, fmt.Errorf("failed to encode log field %s: %w", field.Key, err)
+			}
+		}
+		if err := enc.EndRecord(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	buf.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(field.Key)
+		value, _ := json.Marshal(field.Value)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+// forwardQueueSize is how many records may wait for the host before new
+// ones are dropped.
+const forwardQueueSize = 256
+
+// forwardFlushTimeout bounds how long a response waits for the records
+// queued before it.
+const forwardFlushTimeout = time.Second
+
+// forwarder sends log records to the host from its own goroutine, so that
+// logging never blocks on a host that is busy or not reading. Records that
+// do not fit in the queue are dropped and counted.
+type forwarder struct {
+	// mu guards closing queue against sends.
+	mu      sync.Mutex
+	closed  bool
+	queue   chan forwardItem
+	done    chan struct{}
+	dropped atomic.Int64
+}
+
+// forwardItem is a record to send, or a flush marker closed once every
+// record queued before it was sent.
+type forwardItem struct {
+	record  *pb.LogRecord
+	flushed chan struct{}
+}
+
+func newForwarder() *forwarder {
+	f := &forwarder{
+		queue: make(chan forwardItem, forwardQueueSize),
+		done:  make(chan struct{}),
+	}
+	go f.run()
+	return f
+}
+
+func (f *forwarder) run() {
+	defer close(f.done)
+	for item := range f.queue {
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+		if err := gsplug.SendLogRecord(item.record); err != nil {
+			f.dropped.Add(1)
+		}
+	}
+}
+
+// send queues entry as a LogRecord.
+func (f *forwarder) send(entry Entry) error {
+	record := &pb.LogRecord{
+		TimeUnixNano: entry.Time.UnixNano(),
+		Level:        int32(entry.Level),
+		Message:      entry.Message,
+		RequestId:    entry.RequestID,
+		Plugin:       entry.Plugin,
+		Caller:       entry.Caller,
+	}
+	for _, attr := range entry.Attrs {
+		record.Attrs = append(record.Attrs, &pb.LogAttr{Key: attr.Key, Value: attr.Value})
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		f.dropped.Add(1)
+		return nil
+	}
+	select {
+	case f.queue <- forwardItem{record: record}:
+	default:
+		f.dropped.Add(1)
+	}
+	return nil
+}
+
+// flush waits, at most timeout, until the records queued so far were sent.
+func (f *forwarder) flush(timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	flushed := make(chan struct{})
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return
+	}
+	select {
+	case f.queue <- forwardItem{flushed: flushed}:
+	case <-timer.C:
+		f.mu.Unlock()
+		return
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-flushed:
+	case <-timer.C:
+	}
+}
+
+// close sends the queued records, waiting at most timeout.
+func (f *forwarder) close(timeout time.Duration) {
+	f.mu.Lock()
+	if !f.closed {
+		f.closed = true
+		close(f.queue)
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-f.done:
+	case <-time.After(timeout):
+	}
+}
@@ -1,9 +1,10 @@
 package logger
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"path/filepath"
+	"log/slog"
+	"runtime"
 	"sync"
 	"time"
 
@@ -12,114 +13,277 @@ import (
 )
 
 type RateLimitedLogger struct {
-	logger          *log.Logger
-	fileLogger      *log.Logger
-	lastLogTime     map[string]time.Time
-	logInterval     time.Duration
-	mu              sync.Mutex
-	logFile         *os.File
-	logFileName     string
-	updatedLogFiles map[string]bool
+	// sinks receive every record written: the log file, then the console
+	// and any Options.Sinks.
+	sinks     []slog.Handler
+	forwarder *forwarder
+	limiter   RateLimiter
+	// flush fires when the limiter's next window closes, so summaries are
+	// written on time even if nothing else is logged.
+	flush  *time.Timer
+	level  slog.LevelVar
+	mu     sync.Mutex
+	file   *rotatingFile
+	closed bool
+	stats  sessionStats
+	// removeHooks uninstall the hooks the logger installed in gsplug.
+	removeHooks []func()
 }
 
+// GetLogFileName returns the path of the file currently written, which
+// changes when the log rotates.
 func (l *RateLimitedLogger) GetLogFileName() string {
-	return l.logFileName
+	return l.file.Name()
 }
 
+// NewRateLimitedLogger creates a logger for the plugin with DefaultOptions.
 func NewRateLimitedLogger(pluginName string) (*RateLimitedLogger, error) {
+	return NewRateLimitedLoggerWithOptions(pluginName, DefaultOptions())
+}
+
+// NewRateLimitedLoggerWithHandler creates a logger with DefaultOptions that
+// sends records to sink instead of the console. The log file is still
+// written.
+func NewRateLimitedLoggerWithHandler(pluginName string, sink slog.Handler) (*RateLimitedLogger, error) {
+	opts := DefaultOptions()
+	opts.Console = nil
+	opts.Sinks = []slog.Handler{sink}
+	return NewRateLimitedLoggerWithOptions(pluginName, opts)
+}
+
+// NewRateLimitedLoggerWithOptions creates a logger writing to a new file in
+// the plugin's log directory, to opts.Console, to the host when opts.Forward
+// is set and to opts.Sinks. Old log files are pruned according to opts
+// before it returns.
+func NewRateLimitedLoggerWithOptions(pluginName string, opts Options) (*RateLimitedLogger, error) {
+	if !opts.Format.valid() {
+		return nil, fmt.Errorf("unknown log format %q", opts.Format)
+	}
 	logDir, err := gsplug.GetPluginLogDir(pluginName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get plugin log directory: %w", err)
 	}
 
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	file, err := openRotatingFile(logDir, pluginName, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	date := time.Now().Format("20060102")
-	index := 0
-	var logFile *os.File
+	sinks := []slog.Handler{newFileSink(file, opts.Format, pluginName)}
+	if opts.Console != nil {
+		sinks = append(sinks, log.NewWithOptions(opts.Console, log.Options{
+			ReportCaller:    true,
+			ReportTimestamp: true,
+			Level:           log.DebugLevel,
+		}))
+	}
+	var forward *forwarder
+	if opts.Forward {
+		forward = newForwarder()
+		sinks = append(sinks, &entryHandler{plugin: pluginName, emit: forward.send})
+	}
+	sinks = append(sinks, opts.Sinks...)
 
-	for {
-		fileName := fmt.Sprintf("%s_%s_%02d.log", pluginName, date, index)
-		filePath := filepath.Join(logDir, fileName)
-		file, openErr := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
-		if os.IsExist(openErr) {
-			index++
-			continue
-		}
-		if openErr != nil {
-			return nil, fmt.Errorf("failed to create log file: %w", openErr)
-		}
-		logFile = file
-		break
+	l := &RateLimitedLogger{
+		sinks:     sinks,
+		forwarder: forward,
+		limiter:   opts.RateLimit,
+		file:      file,
+		stats:     sessionStats{plugin: pluginName, started: time.Now()},
+	}
+	l.level.Set(slog.LevelDebug)
+	if forward != nil {
+		l.removeHooks = append(l.removeHooks, gsplug.SetLogFlusher(func() {
+			forward.flush(forwardFlushTimeout)
+		}))
 	}
+	if opts.LogRequests {
+		l.removeHooks = append(l.removeHooks, gsplug.SetRequestHook(l.StartRequest))
+	}
+	return l, nil
+}
 
-	fileLogger := log.NewWithOptions(logFile, log.Options{
-		ReportCaller:    true,
-		ReportTimestamp: true,
-		Level:           log.DebugLevel,
-	})
+// Log writes message to the log file and sinks unless the rate limiter
+// drops it. Records the limiter dropped are reported as "suppressed N
+// similar messages" once their window closes. Secret values and the values
+// of secret keys are redacted before anything is written.
+func (l *RateLimitedLogger) Log(level log.Level, message string, keyvals ...interface{}) {
+	l.log(level, message, keyvals)
+}
 
-	consoleLogger := log.NewWithOptions(os.Stderr, log.Options{
-		ReportCaller:    true,
-		ReportTimestamp: true,
-		Level:           log.DebugLevel,
-	})
+// log records the caller two frames up, the code calling Log or one of the
+// level methods.
+func (l *RateLimitedLogger) log(level log.Level, message string, keyvals []interface{}) {
+	if !l.Enabled(slog.Level(level)) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	record := slog.NewRecord(time.Now(), slog.Level(level), message, pcs[0])
+	record.Add(keyvals...)
+	l.handle(context.Background(), record, &handler{logger: l, sinks: l.sinks})
+}
+
+// handle redacts and rate limits a record and writes it to h's sinks.
+func (l *RateLimitedLogger) handle(ctx context.Context, record slog.Record, h *handler) error {
+	record = redactRecord(record)
 
-	return &RateLimitedLogger{
-		logger:          consoleLogger,
-		fileLogger:      fileLogger,
-		lastLogTime:     make(map[string]time.Time),
-		logInterval:     time.Second * 5,
-		logFile:         logFile,
-		logFileName:     logFile.Name(),
-		updatedLogFiles: make(map[string]bool),
-	}, nil
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil
+	}
+	if l.limiter != nil {
+		l.writeSummaries(ctx, l.limiter.Summaries(record.Time, false))
+		if !h.unlimited && !l.limiter.Allow(h.limiterRecord(record)) {
+			l.stats.suppressed++
+			l.scheduleFlush()
+			return nil
+		}
+	}
+	l.stats.record(record)
+	return writeRecord(ctx, h.sinks, record)
 }
 
-func (l *RateLimitedLogger) Log(level log.Level, message string, keyvals ...interface{}) {
+// writeSummaries writes a "suppressed N similar messages" record, stamped
+// with the time its window closed, for each summary. They are not counted
+// in Stats; the dropped records already are, as Suppressed.
+func (l *RateLimitedLogger) writeSummaries(ctx context.Context, summaries []Summary) {
+	for _, summary := range summaries {
+		record := slog.NewRecord(summary.Closed, slog.Level(summary.Level),
+			fmt.Sprintf("suppressed %d similar messages", summary.Count), 0)
+		record.Add("suppressed_message", summary.Message)
+		record.Add(summary.Keyvals...)
+		writeRecord(ctx, l.sinks, record)
+	}
+}
+
+// scheduleFlush arms the flush timer for the limiter's next due summary.
+// Callers must hold l.mu.
+func (l *RateLimitedLogger) scheduleFlush() {
+	due := l.limiter.Due()
+	if due.IsZero() {
+		if l.flush != nil {
+			l.flush.Stop()
+		}
+		return
+	}
+	if l.flush == nil {
+		l.flush = time.AfterFunc(time.Until(due), l.flushDue)
+		return
+	}
+	l.flush.Reset(time.Until(due))
+}
+
+// flushDue writes the summaries whose window has closed.
+func (l *RateLimitedLogger) flushDue() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	now := time.Now()
-	if lastLog, exists := l.lastLogTime[message]; !exists || now.Sub(lastLog) >= l.logInterval {
-		l.logger.Log(level, message, keyvals...)
-		l.fileLogger.Log(level, message, keyvals...)
-		l.lastLogTime[message] = now
-		l.updatedLogFiles[l.logFileName] = true
+	if l.closed {
+		return
 	}
+	l.writeSummaries(context.Background(), l.limiter.Summaries(time.Now(), false))
+	l.scheduleFlush()
+}
+
+func writeRecord(ctx context.Context, sinks []slog.Handler, record slog.Record) error {
+	var firstErr error
+	for _, sink := range sinks {
+		if !sink.Enabled(ctx, record.Level) {
+			continue
+		}
+		if err := sink.Handle(ctx, record.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Enabled reports whether records at level are written.
+func (l *RateLimitedLogger) Enabled(level slog.Level) bool {
+	return level >= l.level.Level()
 }
 
 func (l *RateLimitedLogger) Info(message string, keyvals ...interface{}) {
-	l.Log(log.InfoLevel, message, keyvals...)
+	l.log(log.InfoLevel, message, keyvals)
 }
 
 func (l *RateLimitedLogger) Debug(message string, keyvals ...interface{}) {
-	l.Log(log.DebugLevel, message, keyvals...)
+	l.log(log.DebugLevel, message, keyvals)
 }
 
 func (l *RateLimitedLogger) Error(message string, keyvals ...interface{}) {
-	l.Log(log.ErrorLevel, message, keyvals...)
+	l.log(log.ErrorLevel, message, keyvals)
 }
 
 func (l *RateLimitedLogger) Warn(message string, keyvals ...interface{}) {
-	l.Log(log.WarnLevel, message, keyvals...)
+	l.log(log.WarnLevel, message, keyvals)
 }
 
+// GetUpdatedLogFiles returns the full paths of the files written during
+// this session, oldest first. Rotated files that were compressed are listed
+// under their .gz name.
 func (l *RateLimitedLogger) GetUpdatedLogFiles() []string {
+	return l.file.Files()
+}
+
+func (l *RateLimitedLogger) SetLogLevel(level log.Level) {
+	l.level.Set(slog.Level(level))
+}
+
+// Close reports any records the rate limiter is still holding back, sends
+// queued records to the host, then flushes and closes the log file.
+// Messages logged afterwards are dropped.
+func (l *RateLimitedLogger) Close() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	files := make([]string, 0, len(l.updatedLogFiles))
-	for file := range l.updatedLogFiles {
-		// Ensure this is the full path
-		files = append(files, file)
+	if l.closed {
+		return nil
+	}
+	if l.limiter != nil {
+		if l.flush != nil {
+			l.flush.Stop()
+		}
+		l.writeSummaries(context.Background(), l.limiter.Summaries(time.Now(), true))
+	}
+	l.closed = true
+	l.stats.ended = time.Now()
+	for _, remove := range l.removeHooks {
+		remove()
+	}
+	if l.forwarder != nil {
+		l.forwarder.close(time.Second)
 	}
-	return files
+	return l.file.Close()
 }
 
-func (l *RateLimitedLogger) SetLogLevel(level log.Level) {
-	l.logger.SetLevel(level)
-	l.fileLogger.SetLevel(level)
+// redactRecord returns a copy of record with secrets removed from the
+// message and attribute values, keeping groups intact.
+func redactRecord(record slog.Record) slog.Record {
+	redacted := slog.NewRecord(record.Time, record.Level, gsplug.Redact(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(attr))
+		return true
+	})
+	return redacted
+}
+
+func redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		group := value.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, member := range group {
+			attrs[i] = redactAttr(member)
+		}
+		return slog.Attr{Key: attr.Key, Value: slog.GroupValue(attrs...)}
+	}
+	if value.Kind() != slog.KindAny && value.Kind() != slog.KindString {
+		return slog.Attr{Key: attr.Key, Value: value}
+	}
+	keyvals := gsplug.RedactKeyvals([]interface{}{attr.Key, value.Any()})
+	return slog.Any(attr.Key, keyvals[1])
 }
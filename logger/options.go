@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+)
+
+// Options configures a RateLimitedLogger.
+type Options struct {
+	// MaxFileSize starts a new log file once the current one would grow past
+	// this many bytes. Zero never rotates.
+	MaxFileSize int64
+	// MaxFiles, MaxAge and MaxTotalSize bound the plugin's log directory.
+	// They are applied at startup and after every rotation, deleting the
+	// oldest files first. Zero disables a limit.
+	MaxFiles     int
+	MaxAge       time.Duration
+	MaxTotalSize int64
+	// Compress gzips log files once they are rotated.
+	Compress bool
+	// Format is the log file's layout; empty means FormatText.
+	Format Format
+	// RateLimit drops repeated records and summarizes what it dropped. Nil
+	// writes every record.
+	RateLimit RateLimiter
+	// Console receives records in charmbracelet/log's text format. Nil
+	// disables console output.
+	Console io.Writer
+	// Forward sends every record written to the host as a LogRecord
+	// message. Only enable it when gsplug.LogForwardingEnabled reports that
+	// the host accepts them.
+	Forward bool
+	// Sinks receive every record written, after the log file and console.
+	Sinks []slog.Handler
+	// LogRequests runs every command RunPlugin handles inside StartRequest,
+	// so each logs when it starts and finishes and FromContext returns its
+	// logger in CommandContext functions. Only the latest logger created
+	// with it is used, until it is closed.
+	LogRequests bool
+}
+
+// DefaultOptions returns the options used by NewRateLimitedLogger. Records
+// are forwarded when the host asked for them, and otherwise written to
+// stderr.
+func DefaultOptions() Options {
+	forward := gsplug.LogForwardingEnabled()
+	var console io.Writer = os.Stderr
+	if forward {
+		console = nil
+	}
+	return Options{
+		MaxFileSize:  10 << 20,
+		MaxFiles:     50,
+		MaxAge:       30 * 24 * time.Hour,
+		MaxTotalSize: 200 << 20,
+		RateLimit:    NewIntervalLimiter(5 * time.Second),
+		Console:      console,
+		Forward:      forward,
+		LogRequests:  true,
+	}
+}
@@ -0,0 +1,387 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+)
+
+// DefaultFollowInterval is how often Follow checks for new records when the
+// query's interval is zero.
+const DefaultFollowInterval = 500 * time.Millisecond
+
+// Query selects records from plugin log directories. Zero fields match
+// everything.
+type Query struct {
+	// Dir holds one log directory per plugin; empty means gsplug.GetLogsDir.
+	Dir string
+	// Plugins limits the query to these plugins' directories.
+	Plugins []string
+	// Levels keeps records at these levels; see AtLeast.
+	Levels []log.Level
+	// Since and Until bound the records' timestamps, inclusive.
+	Since time.Time
+	Until time.Time
+	// RequestID keeps the records of one request.
+	RequestID string
+	// Attrs keeps records whose attributes, or plugin and caller fields,
+	// have these values.
+	Attrs map[string]string
+}
+
+// AtLeast returns level and every level above it, for Query.Levels.
+func AtLeast(level log.Level) []log.Level {
+	var levels []log.Level
+	for _, l := range []log.Level{log.DebugLevel, log.InfoLevel, log.WarnLevel, log.ErrorLevel, log.FatalLevel} {
+		if l >= level {
+			levels = append(levels, l)
+		}
+	}
+	return levels
+}
+
+// Match reports whether entry satisfies the query's filters.
+func (q Query) Match(entry Entry) bool {
+	if len(q.Plugins) > 0 && !containsString(q.Plugins, entry.Plugin) {
+		return false
+	}
+	if len(q.Levels) > 0 && !containsLevel(q.Levels, entry.Level) {
+		return false
+	}
+	if !q.Since.IsZero() && entry.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && entry.Time.After(q.Until) {
+		return false
+	}
+	if q.RequestID != "" && entry.RequestID != q.RequestID {
+		return false
+	}
+	for key, want := range q.Attrs {
+		var value string
+		switch key {
+		case FieldPlugin:
+			value = entry.Plugin
+		case FieldCaller:
+			value = entry.Caller
+		default:
+			value, _ = entry.Attr(key)
+		}
+		if value != want {
+			return false
+		}
+	}
+	return true
+}
+
+// Position records how far each log file has been read, so FollowFrom can
+// continue exactly where SearchPosition stopped. Files are keyed by their
+// uncompressed name, so a file that is compressed after rotation keeps its
+// offset.
+type Position struct {
+	files map[string]filePosition
+}
+
+// filePosition is the number of uncompressed bytes read from a file, and
+// whether its compressed copy has been read to the end.
+type filePosition struct {
+	offset int64
+	done   bool
+}
+
+func (p *Position) clone() *Position {
+	files := make(map[string]filePosition, len(p.files))
+	for key, pos := range p.files {
+		files[key] = pos
+	}
+	return &Position{files: files}
+}
+
+// positionKey names a file by its uncompressed path.
+func positionKey(path string) string {
+	return strings.TrimSuffix(path, ".gz")
+}
+
+// Search reads the log files selected by the query and returns the matching
+// records merged in chronological order.
+func Search(q Query) ([]Entry, error) {
+	entries, _, err := SearchPosition(q)
+	return entries, err
+}
+
+// SearchPosition is like Search and also returns the position it reached,
+// for FollowFrom.
+func SearchPosition(q Query) ([]Entry, *Position, error) {
+	files, err := q.files()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var entries []Entry
+	pos := &Position{files: make(map[string]filePosition, len(files))}
+	for _, file := range files {
+		key := positionKey(file.path)
+		if _, ok := pos.files[key]; ok {
+			// Both copies exist while a rotated file is being compressed.
+			continue
+		}
+		compressed := strings.HasSuffix(file.path, ".gz")
+		if !q.Since.IsZero() && file.modTime.Before(q.Since) {
+			pos.files[key] = filePosition{offset: file.size, done: compressed}
+			continue
+		}
+		fileEntries, next, err := readFrom(file.path, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		pos.files[key] = filePosition{offset: next, done: compressed}
+		for _, entry := range fileEntries {
+			if q.Match(entry) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, pos, nil
+}
+
+// Follow calls fn with the records appended to the selected log files until
+// ctx is done or fn fails, checking every interval (DefaultFollowInterval
+// when zero). Records already in the files are skipped; use SearchPosition
+// and FollowFrom to show them without a gap. New files, such as those
+// started by rotation, are followed from their beginning.
+func Follow(ctx context.Context, q Query, interval time.Duration, fn func(Entry) error) error {
+	return FollowFrom(ctx, q, nil, interval, fn)
+}
+
+// FollowFrom is like Follow but starts at pos, usually returned by
+// SearchPosition, so records written between the search and the first check
+// are not lost. A nil pos starts at the current end of the files. When a
+// file is rotated and compressed between two checks, the rest of it is read
+// from the compressed copy.
+func FollowFrom(ctx context.Context, q Query, pos *Position, interval time.Duration, fn func(Entry) error) error {
+	if interval == 0 {
+		interval = DefaultFollowInterval
+	}
+
+	if pos == nil {
+		files, err := q.files()
+		if err != nil {
+			return err
+		}
+		pos = &Position{files: make(map[string]filePosition, len(files))}
+		for _, file := range files {
+			pos.files[positionKey(file.path)] = filePosition{offset: file.size, done: strings.HasSuffix(file.path, ".gz")}
+		}
+	} else {
+		pos = pos.clone()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		entries, err := pos.advance(q)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// advance reads the matching records written since pos and moves pos past
+// them.
+func (p *Position) advance(q Query) ([]Entry, error) {
+	files, err := q.files()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		key := positionKey(file.path)
+		seen[key] = true
+		current := p.files[key]
+		if current.done {
+			continue
+		}
+		compressed := strings.HasSuffix(file.path, ".gz")
+		if !compressed {
+			if file.size < current.offset {
+				current.offset = 0
+			}
+			if file.size == current.offset {
+				continue
+			}
+		}
+		fileEntries, next, err := readFrom(file.path, current.offset)
+		if err != nil {
+			return nil, err
+		}
+		p.files[key] = filePosition{offset: next, done: compressed}
+		for _, entry := range fileEntries {
+			if q.Match(entry) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	for key := range p.files {
+		if !seen[key] {
+			delete(p.files, key)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, nil
+}
+
+// readFrom parses the complete lines of path after offset and returns the
+// offset following the last of them. Offsets count uncompressed bytes;
+// a compressed file is complete, so its last line is read even without a
+// trailing newline.
+func readFrom(path string, offset int64) ([]Entry, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, offset, nil
+		}
+		return nil, offset, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	var input io.Reader = file
+	compressed := strings.HasSuffix(path, ".gz")
+	if compressed {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, offset, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		defer zr.Close()
+		if _, err := io.CopyN(io.Discard, zr, offset); err != nil && !errors.Is(err, io.EOF) {
+			return nil, offset, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		input = zr
+	} else if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, offset, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	end := len(data)
+	if !compressed {
+		end = bytes.LastIndexByte(data, '\n') + 1
+	}
+	if end == 0 {
+		return nil, offset, nil
+	}
+
+	reader := NewReader(bytes.NewReader(data[:end]))
+	reader.Plugin = pluginFromFileName(path)
+	var entries []Entry
+	for {
+		entry, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, offset + int64(end), nil
+}
+
+// files lists the log files of the selected plugins, oldest first.
+func (q Query) files() ([]logFileInfo, error) {
+	dir := q.Dir
+	if dir == "" {
+		var err error
+		if dir, err = gsplug.GetLogsDir(); err != nil {
+			return nil, err
+		}
+	}
+
+	plugins := q.Plugins
+	if len(plugins) == 0 {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to read log directory: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				plugins = append(plugins, entry.Name())
+			}
+		}
+	}
+
+	var files []logFileInfo
+	for _, plugin := range plugins {
+		entries, err := os.ReadDir(filepath.Join(dir, plugin))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read log directory: %w", err)
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasSuffix(name, ".log") && !strings.HasSuffix(name, ".log.gz") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			files = append(files, logFileInfo{
+				path:    filepath.Join(dir, plugin, name),
+				size:    info.Size(),
+				modTime: info.ModTime(),
+			})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	return files, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsLevel(list []log.Level, level log.Level) bool {
+	for _, item := range list {
+		if item == level {
+			return true
+		}
+	}
+	return false
+}
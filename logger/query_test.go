@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// logLine returns a JSON record logged offset after t0.
+func logLine(t *testing.T, offset time.Duration, level log.Level, message string, attrs ...Attr) string {
+	t.Helper()
+	line, err := EncodeEntry(FormatJSON, Entry{Time: t0.Add(offset), Level: level, Message: message, Attrs: attrs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(line)
+}
+
+func appendFile(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(strings.Join(lines, "")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// compress replaces path with path.gz, as rotation does.
+func compress(t *testing.T, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.Create(path + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(out)
+	zw.Write(data)
+	zw.Close()
+	out.Close()
+	os.Remove(path)
+}
+
+func messagesOf(entries []Entry) string {
+	messages := make([]string, len(entries))
+	for i, entry := range entries {
+		messages[i] = entry.Plugin + ":" + entry.Message
+	}
+	return strings.Join(messages, ",")
+}
+
+func TestSearch(t *testing.T) {
+	dir := t.TempDir()
+	appendFile(t, filepath.Join(dir, "alpha", "alpha_20241001_00.log"),
+		logLine(t, 0, log.InfoLevel, "a1"),
+		logLine(t, 2*time.Second, log.ErrorLevel, "a2", Attr{"repo", "sdk"}),
+		"not a record\n",
+	)
+	appendFile(t, filepath.Join(dir, "beta", "beta_20241001_00.log"),
+		logLine(t, time.Second, log.WarnLevel, "b1", Attr{"repo", "sdk"}),
+		logLine(t, 3*time.Second, log.DebugLevel, "b2"),
+	)
+	compress(t, filepath.Join(dir, "beta", "beta_20241001_00.log"))
+
+	tests := []struct {
+		name  string
+		query Query
+		want  string
+	}{
+		{"all in time order", Query{}, "alpha:a1,beta:b1,alpha:a2,beta:b2"},
+		{"plugin", Query{Plugins: []string{"beta"}}, "beta:b1,beta:b2"},
+		{"level", Query{Levels: AtLeast(log.WarnLevel)}, "beta:b1,alpha:a2"},
+		{"time range", Query{Since: t0.Add(time.Second), Until: t0.Add(2 * time.Second)}, "beta:b1,alpha:a2"},
+		{"attribute", Query{Attrs: map[string]string{"repo": "sdk"}}, "beta:b1,alpha:a2"},
+		{"plugin field", Query{Attrs: map[string]string{FieldPlugin: "alpha"}}, "alpha:a1,alpha:a2"},
+		{"missing plugin", Query{Plugins: []string{"gamma"}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.Dir = dir
+			entries, err := Search(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := messagesOf(entries); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// follow runs FollowFrom for a few intervals and returns what it delivered.
+func follow(t *testing.T, q Query, pos *Position) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	var entries []Entry
+	err := FollowFrom(ctx, q, pos, 20*time.Millisecond, func(entry Entry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("FollowFrom returned %v", err)
+	}
+	return messagesOf(entries)
+}
+
+func TestFollowFromSearchPosition(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "alpha", "alpha_20241001_00.log")
+	gap := logLine(t, time.Second, log.InfoLevel, "gap")
+	// The search sees a complete record and the start of one being written.
+	appendFile(t, path, logLine(t, 0, log.InfoLevel, "before"), gap[:10])
+	q := Query{Dir: dir}
+
+	entries, pos, err := SearchPosition(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := messagesOf(entries); got != "alpha:before" {
+		t.Fatalf("search found %s", got)
+	}
+
+	// Written between the search and following: the rest of that record and
+	// another, then rotation and compression of the file.
+	appendFile(t, path, gap[10:], logLine(t, 2*time.Second, log.InfoLevel, "rotated"))
+	compress(t, path)
+	appendFile(t, filepath.Join(dir, "alpha", "alpha_20241001_01.log"), logLine(t, 3*time.Second, log.InfoLevel, "new file"))
+
+	if got, want := follow(t, q, pos), "alpha:gap,alpha:rotated,alpha:new file"; got != want {
+		t.Fatalf("followed %s, want %s", got, want)
+	}
+}
+
+func TestFollowStartsAtEnd(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "alpha", "alpha_20241001_00.log")
+	appendFile(t, path, logLine(t, 0, log.InfoLevel, "old"))
+	compress(t, path)
+	appendFile(t, filepath.Join(dir, "alpha", "alpha_20241001_01.log"), logLine(t, time.Second, log.InfoLevel, "current"))
+	q := Query{Dir: dir, Levels: AtLeast(log.WarnLevel)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	delivered := make(chan Entry, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- Follow(ctx, q, 10*time.Millisecond, func(entry Entry) error {
+			delivered <- entry
+			return nil
+		})
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	appendFile(t, filepath.Join(dir, "alpha", "alpha_20241001_01.log"),
+		logLine(t, 2*time.Second, log.InfoLevel, "filtered"),
+		logLine(t, 3*time.Second, log.WarnLevel, "appended"),
+	)
+
+	select {
+	case entry := <-delivered:
+		if entry.Message != "appended" {
+			t.Fatalf("delivered %q first", entry.Message)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("appended record was not delivered")
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Follow returned %v", err)
+	}
+	if len(delivered) != 0 {
+		t.Fatalf("delivered %d more records", len(delivered))
+	}
+}
@@ -0,0 +1,278 @@
+package logger
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// DefaultMaxKeys bounds the keys a rate limiter remembers when its MaxKeys
+// is zero. The least recently seen key is forgotten first.
+const DefaultMaxKeys = 1000
+
+// Record is a log call as seen by a RateLimiter, after redaction.
+type Record struct {
+	Time    time.Time
+	Level   log.Level
+	Message string
+	Keyvals []interface{}
+}
+
+// Summary reports the records a RateLimiter dropped for one key. Record is
+// the first record dropped.
+type Summary struct {
+	Record
+	Count int
+	// Closed is when the key's window closed, or when it was flushed or
+	// forgotten; the summary line is stamped with it.
+	Closed time.Time
+}
+
+// RateLimiter decides which records a RateLimitedLogger writes. The logger
+// serializes calls, so implementations need no locking of their own.
+type RateLimiter interface {
+	// Allow reports whether r should be written.
+	Allow(r Record) bool
+	// Summaries returns, and forgets, the records dropped for keys whose
+	// window has closed by now, or for every key when flush is set.
+	Summaries(now time.Time, flush bool) []Summary
+	// Due returns when Summaries next has something to report, or the zero
+	// time when nothing was dropped. The logger calls Summaries then.
+	Due() time.Time
+}
+
+// KeyFunc groups records: records with the same key share a limit.
+type KeyFunc func(Record) string
+
+// MessageKey groups records by message.
+func MessageKey(r Record) string {
+	return r.Message
+}
+
+// KeyWith groups records by message and the values of the given keys, so
+// that, say, the same error for different repositories is limited
+// separately.
+func KeyWith(keys ...string) KeyFunc {
+	return func(r Record) string {
+		var b strings.Builder
+		b.WriteString(r.Message)
+		for _, key := range keys {
+			for i := 0; i+1 < len(r.Keyvals); i += 2 {
+				if fmt.Sprint(r.Keyvals[i]) == key {
+					fmt.Fprintf(&b, "\x00%s=%v", key, r.Keyvals[i+1])
+				}
+			}
+		}
+		return b.String()
+	}
+}
+
+// IntervalLimiter writes a record at most once per interval for each key.
+type IntervalLimiter struct {
+	// Key groups records; nil means MessageKey.
+	Key KeyFunc
+	// Interval applies to levels missing from Levels.
+	Interval time.Duration
+	// Levels overrides Interval per level. A zero interval never
+	// suppresses the level.
+	Levels map[log.Level]time.Duration
+	// MaxKeys bounds the keys remembered; zero means DefaultMaxKeys.
+	MaxKeys int
+
+	keys keyTable
+}
+
+// NewIntervalLimiter limits every key to one record per interval, except
+// errors and fatal records, which are never suppressed.
+func NewIntervalLimiter(interval time.Duration) *IntervalLimiter {
+	return &IntervalLimiter{
+		Interval: interval,
+		Levels:   map[log.Level]time.Duration{log.ErrorLevel: 0, log.FatalLevel: 0},
+	}
+}
+
+func (l *IntervalLimiter) Allow(r Record) bool {
+	interval, ok := l.Levels[r.Level]
+	if !ok {
+		interval = l.Interval
+	}
+	if interval <= 0 {
+		return true
+	}
+
+	entry := l.keys.get(keyOf(l.Key, r), l.MaxKeys, r.Time)
+	if entry.last.IsZero() || r.Time.Sub(entry.last) >= interval {
+		entry.last = r.Time
+		entry.until = r.Time.Add(interval)
+		return true
+	}
+	l.keys.suppress(entry, r)
+	return false
+}
+
+func (l *IntervalLimiter) Summaries(now time.Time, flush bool) []Summary {
+	return l.keys.summaries(now, flush)
+}
+
+func (l *IntervalLimiter) Due() time.Time {
+	return l.keys.nextDue()
+}
+
+// TokenBucketLimiter allows bursts of Burst records per key, refilled at
+// Rate records per second. With a Rate of zero a key is silenced once its
+// burst is spent.
+type TokenBucketLimiter struct {
+	// Key groups records; nil means MessageKey.
+	Key   KeyFunc
+	Rate  float64
+	Burst int
+	// MaxKeys bounds the keys remembered; zero means DefaultMaxKeys.
+	MaxKeys int
+
+	keys keyTable
+}
+
+func NewTokenBucketLimiter(rate float64, burst int) *TokenBucketLimiter {
+	return &TokenBucketLimiter{Rate: rate, Burst: burst}
+}
+
+func (l *TokenBucketLimiter) Allow(r Record) bool {
+	entry := l.keys.get(keyOf(l.Key, r), l.MaxKeys, r.Time)
+	if entry.last.IsZero() {
+		entry.tokens = float64(l.Burst)
+	} else {
+		entry.tokens += r.Time.Sub(entry.last).Seconds() * l.Rate
+		if entry.tokens > float64(l.Burst) {
+			entry.tokens = float64(l.Burst)
+		}
+	}
+	entry.last = r.Time
+
+	if entry.tokens >= 1 {
+		entry.tokens--
+		return true
+	}
+	if l.Rate > 0 {
+		entry.until = r.Time.Add(time.Duration((1 - entry.tokens) / l.Rate * float64(time.Second)))
+	} else {
+		entry.until = r.Time.Add(time.Duration(math.MaxInt64))
+	}
+	l.keys.suppress(entry, r)
+	return false
+}
+
+func (l *TokenBucketLimiter) Summaries(now time.Time, flush bool) []Summary {
+	return l.keys.summaries(now, flush)
+}
+
+func (l *TokenBucketLimiter) Due() time.Time {
+	return l.keys.nextDue()
+}
+
+func keyOf(fn KeyFunc, r Record) string {
+	if fn == nil {
+		return MessageKey(r)
+	}
+	return fn(r)
+}
+
+type limitEntry struct {
+	key    string
+	last   time.Time
+	tokens float64
+	// until is when the current window closes and dropped records are
+	// reported.
+	until      time.Time
+	suppressed int
+	first      Record
+}
+
+// keyTable holds per-key state for the limiters, forgetting the least
+// recently used key beyond its size. Dropped records of a forgotten key are
+// reported right away.
+type keyTable struct {
+	entries map[string]*list.Element
+	order   *list.List
+	evicted []Summary
+	// due is the earliest window close among entries with dropped records.
+	due time.Time
+}
+
+func (t *keyTable) get(key string, maxKeys int, now time.Time) *limitEntry {
+	if t.entries == nil {
+		t.entries = make(map[string]*list.Element)
+		t.order = list.New()
+	}
+	if element, ok := t.entries[key]; ok {
+		t.order.MoveToFront(element)
+		return element.Value.(*limitEntry)
+	}
+
+	if maxKeys <= 0 {
+		maxKeys = DefaultMaxKeys
+	}
+	for t.order.Len() >= maxKeys {
+		oldest := t.order.Back()
+		entry := oldest.Value.(*limitEntry)
+		if entry.suppressed > 0 {
+			t.evicted = append(t.evicted, Summary{Record: entry.first, Count: entry.suppressed, Closed: now})
+		}
+		t.order.Remove(oldest)
+		delete(t.entries, entry.key)
+	}
+	entry := &limitEntry{key: key}
+	t.entries[key] = t.order.PushFront(entry)
+	return entry
+}
+
+func (t *keyTable) suppress(entry *limitEntry, r Record) {
+	if entry.suppressed == 0 {
+		entry.first = r
+	}
+	entry.suppressed++
+	if t.due.IsZero() || entry.until.Before(t.due) {
+		t.due = entry.until
+	}
+}
+
+func (t *keyTable) summaries(now time.Time, flush bool) []Summary {
+	summaries := t.evicted
+	t.evicted = nil
+	if t.due.IsZero() || (!flush && now.Before(t.due)) {
+		return summaries
+	}
+
+	t.due = time.Time{}
+	for element := t.order.Back(); element != nil; element = element.Prev() {
+		entry := element.Value.(*limitEntry)
+		if entry.suppressed == 0 {
+			continue
+		}
+		if flush || !now.Before(entry.until) {
+			closed := entry.until
+			if now.Before(closed) {
+				closed = now
+			}
+			summaries = append(summaries, Summary{Record: entry.first, Count: entry.suppressed, Closed: closed})
+			entry.suppressed = 0
+			entry.first = Record{}
+			continue
+		}
+		if t.due.IsZero() || entry.until.Before(t.due) {
+			t.due = entry.until
+		}
+	}
+	return summaries
+}
+
+// nextDue returns when summaries next has something to report.
+func (t *keyTable) nextDue() time.Time {
+	if len(t.evicted) > 0 {
+		return t.evicted[0].Closed
+	}
+	return t.due
+}
@@ -0,0 +1,216 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+func at(offset time.Duration, level log.Level, message string, keyvals ...interface{}) Record {
+	return Record{Time: t0.Add(offset), Level: level, Message: message, Keyvals: keyvals}
+}
+
+func TestIntervalLimiter(t *testing.T) {
+	limiter := NewIntervalLimiter(5 * time.Second)
+
+	steps := []struct {
+		record Record
+		allow  bool
+	}{
+		{at(0, log.InfoLevel, "tick"), true},
+		{at(time.Second, log.InfoLevel, "tick"), false},
+		{at(2*time.Second, log.InfoLevel, "tick"), false},
+		{at(2*time.Second, log.InfoLevel, "other"), true},
+		{at(3*time.Second, log.ErrorLevel, "tick"), true},
+		{at(4*time.Second, log.ErrorLevel, "tick"), true},
+	}
+	for i, step := range steps {
+		if got := limiter.Allow(step.record); got != step.allow {
+			t.Fatalf("step %d: Allow = %v, want %v", i, got, step.allow)
+		}
+	}
+
+	if due := limiter.Due(); !due.Equal(t0.Add(5 * time.Second)) {
+		t.Fatalf("Due = %v, want the window close", due)
+	}
+	if summaries := limiter.Summaries(t0.Add(4*time.Second), false); len(summaries) != 0 {
+		t.Fatalf("summaries before the window closed: %+v", summaries)
+	}
+	summaries := limiter.Summaries(t0.Add(6*time.Second), false)
+	if len(summaries) != 1 {
+		t.Fatalf("got %d summaries, want 1", len(summaries))
+	}
+	s := summaries[0]
+	if s.Count != 2 || s.Message != "tick" || !s.Time.Equal(t0.Add(time.Second)) || !s.Closed.Equal(t0.Add(5*time.Second)) {
+		t.Fatalf("unexpected summary %+v", s)
+	}
+	if !limiter.Due().IsZero() {
+		t.Fatal("Due is set after every summary was reported")
+	}
+
+	if !limiter.Allow(at(6*time.Second, log.InfoLevel, "tick")) {
+		t.Fatal("record after the window was dropped")
+	}
+}
+
+func TestTokenBucketLimiter(t *testing.T) {
+	limiter := NewTokenBucketLimiter(1, 2)
+
+	steps := []struct {
+		offset time.Duration
+		allow  bool
+	}{
+		{0, true},
+		{0, true},
+		{100 * time.Millisecond, false},
+		{time.Second, true},
+		{time.Second, false},
+		{3 * time.Second, true},
+		{3 * time.Second, true},
+		{3 * time.Second, false},
+	}
+	for i, step := range steps {
+		if got := limiter.Allow(at(step.offset, log.InfoLevel, "burst")); got != step.allow {
+			t.Fatalf("step %d: Allow = %v, want %v", i, got, step.allow)
+		}
+	}
+
+	summaries := limiter.Summaries(t0.Add(3*time.Second), true)
+	if len(summaries) != 1 || summaries[0].Count != 3 || !summaries[0].Closed.Equal(t0.Add(3*time.Second)) {
+		t.Fatalf("unexpected flushed summaries %+v", summaries)
+	}
+}
+
+func TestKeyWith(t *testing.T) {
+	limiter := NewIntervalLimiter(time.Minute)
+	limiter.Key = KeyWith("repo")
+
+	if !limiter.Allow(at(0, log.WarnLevel, "sync failed", "repo", "a")) {
+		t.Fatal("first record for a was dropped")
+	}
+	if !limiter.Allow(at(0, log.WarnLevel, "sync failed", "repo", "b")) {
+		t.Fatal("first record for b was dropped")
+	}
+	if limiter.Allow(at(time.Second, log.WarnLevel, "sync failed", "repo", "a", "attempt", 2)) {
+		t.Fatal("repeated record for a was written")
+	}
+}
+
+func TestMaxKeysReportsEvicted(t *testing.T) {
+	limiter := NewIntervalLimiter(time.Minute)
+	limiter.MaxKeys = 2
+
+	limiter.Allow(at(0, log.InfoLevel, "a"))
+	limiter.Allow(at(time.Second, log.InfoLevel, "a"))
+	limiter.Allow(at(2*time.Second, log.InfoLevel, "b"))
+	limiter.Allow(at(3*time.Second, log.InfoLevel, "c"))
+
+	if due := limiter.Due(); !due.Equal(t0.Add(3 * time.Second)) {
+		t.Fatalf("Due = %v, want the eviction time", due)
+	}
+	summaries := limiter.Summaries(t0.Add(3*time.Second), false)
+	if len(summaries) != 1 || summaries[0].Message != "a" || summaries[0].Count != 1 {
+		t.Fatalf("unexpected summaries %+v", summaries)
+	}
+	if !limiter.Allow(at(4*time.Second, log.InfoLevel, "a")) {
+		t.Fatal("forgotten key was still limited")
+	}
+}
+
+// captureHandler records what a logger writes to its sinks.
+type captureHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *captureHandler) Handle(ctx context.Context, record slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, record)
+	return nil
+}
+
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *captureHandler) WithGroup(string) slog.Handler { return h }
+
+func (h *captureHandler) messages() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	messages := make([]string, len(h.records))
+	for i, record := range h.records {
+		messages[i] = record.Message
+	}
+	return messages
+}
+
+func newTestLogger(t *testing.T, opts Options) (*RateLimitedLogger, *captureHandler) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	sink := &captureHandler{}
+	opts.Console = nil
+	opts.Forward = false
+	opts.Sinks = []slog.Handler{sink}
+	l, err := NewRateLimitedLoggerWithOptions("test-plugin", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l, sink
+}
+
+func TestLoggerFlushesSummaryWhenWindowCloses(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	l, sink := newTestLogger(t, Options{RateLimit: NewIntervalLimiter(interval)})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		l.Info("tick", "i", i)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sink.messages()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	messages := sink.messages()
+	if len(messages) != 2 || messages[1] != "suppressed 2 similar messages" {
+		t.Fatalf("logger wrote %q without another log call", messages)
+	}
+
+	sink.mu.Lock()
+	summary := sink.records[1]
+	sink.mu.Unlock()
+	if summary.Time.Before(start.Add(interval)) || summary.Time.After(start.Add(interval+time.Second)) {
+		t.Fatalf("summary stamped %v, want the window close around %v", summary.Time, start.Add(interval))
+	}
+
+	stats := l.Stats()
+	if stats.Counts["info"] != 1 || stats.Suppressed != 2 || stats.Total() != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestLoggerReportsSummariesOnClose(t *testing.T) {
+	l, sink := newTestLogger(t, Options{RateLimit: NewIntervalLimiter(time.Hour)})
+
+	l.Warn("disk low")
+	l.Warn("disk low")
+	l.Error("disk full")
+	l.Error("disk full")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	l.Warn("after close")
+
+	want := "disk low,disk full,disk full,suppressed 1 similar messages"
+	if got := strings.Join(sink.messages(), ","); got != want {
+		t.Fatalf("wrote %s, want %s", got, want)
+	}
+}
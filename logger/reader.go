@@ -0,0 +1,379 @@
+package logger
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/go-logfmt/logfmt"
+)
+
+// ErrMalformed is returned for lines that are not a log record in any of
+// the file formats.
+var ErrMalformed = errors.New("malformed log line")
+
+// textTimeLayout is the timestamp charmbracelet/log writes in FormatText.
+const textTimeLayout = "2006/01/02 15:04:05"
+
+// MaxLineSize is the longest line a Reader parses. Longer lines are
+// skipped and counted.
+const MaxLineSize = 4 << 20
+
+// Reader parses log files in any Format, detecting the format per line.
+// Text lines are parsed on a best-effort basis: the message ends where the
+// trailing key=value pairs start.
+type Reader struct {
+	// Plugin fills Entry.Plugin for records that do not name one, such as
+	// those in text files.
+	Plugin string
+
+	input   *bufio.Reader
+	line    int
+	skipped int
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{input: bufio.NewReaderSize(r, 64<<10)}
+}
+
+// Next returns the next record, or io.EOF at the end of the input. Blank
+// lines, the indented continuation lines of multi-line text values and
+// lines longer than MaxLineSize are skipped; other lines that cannot be
+// parsed return an error wrapping ErrMalformed, after which reading can
+// continue.
+func (r *Reader) Next() (Entry, error) {
+	for {
+		line, tooLong, err := r.readLine()
+		if err != nil {
+			return Entry{}, err
+		}
+		r.line++
+		if tooLong {
+			r.skipped++
+			continue
+		}
+		if strings.TrimSpace(line) == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		entry, err := ParseLine(line)
+		if err != nil {
+			return Entry{}, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		if entry.Plugin == "" {
+			entry.Plugin = r.Plugin
+		}
+		return entry, nil
+	}
+}
+
+// Skipped returns the number of lines skipped for exceeding MaxLineSize.
+func (r *Reader) Skipped() int {
+	return r.skipped
+}
+
+// readLine returns the next line without its line ending. Lines longer than
+// MaxLineSize are read to their end but not returned.
+func (r *Reader) readLine() (string, bool, error) {
+	var line []byte
+	tooLong := false
+	for {
+		chunk, err := r.input.ReadSlice('\n')
+		if !tooLong {
+			if len(line)+len(chunk) > MaxLineSize+1 {
+				tooLong, line = true, nil
+			} else {
+				line = append(line, chunk...)
+			}
+		}
+		switch {
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF) && (len(line) > 0 || tooLong):
+		case err != nil:
+			return "", false, err
+		}
+		return strings.TrimRight(string(line), "\r\n"), tooLong, nil
+	}
+}
+
+// ReadFile reads every record of a log file, gzipped or not, skipping lines
+// that cannot be parsed. Records without a plugin are attributed to the
+// plugin named in the file name.
+func ReadFile(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	var input io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		defer zr.Close()
+		input = zr
+	}
+
+	reader := NewReader(input)
+	reader.Plugin = pluginFromFileName(path)
+	var entries []Entry
+	for {
+		entry, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if errors.Is(err, ErrMalformed) {
+			continue
+		}
+		if err != nil {
+			return entries, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// pluginFromFileName extracts <name> from <name>_<date>_<NN>.log[.gz].
+func pluginFromFileName(path string) string {
+	base := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".gz"), ".log")
+	parts := strings.Split(base, "_")
+	if len(parts) < 3 {
+		return ""
+	}
+	return strings.Join(parts[:len(parts)-2], "_")
+}
+
+// ParseLine parses a single line in any Format.
+func ParseLine(line string) (Entry, error) {
+	switch {
+	case strings.HasPrefix(line, "{"):
+		return parseJSON(line)
+	case strings.HasPrefix(line, FieldTime+"="):
+		return parseLogfmt(line)
+	default:
+		return parseText(line)
+	}
+}
+
+func parseJSON(line string) (Entry, error) {
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+	if _, err := decoder.Token(); err != nil {
+		return Entry{}, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	var fields []Attr
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return Entry{}, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		key, _ := token.(string)
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return Entry{}, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		text, ok := value.(string)
+		if !ok {
+			raw, _ := json.Marshal(value)
+			text = string(raw)
+		}
+		fields = append(fields, Attr{Key: key, Value: text})
+	}
+	return entryFromFields(fields)
+}
+
+func parseLogfmt(line string) (Entry, error) {
+	decoder := logfmt.NewDecoder(strings.NewReader(line))
+	var fields []Attr
+	for decoder.ScanRecord() {
+		for decoder.ScanKeyval() {
+			fields = append(fields, Attr{Key: string(decoder.Key()), Value: string(decoder.Value())})
+		}
+	}
+	if err := decoder.Err(); err != nil {
+		return Entry{}, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return entryFromFields(fields)
+}
+
+// entryFromFields builds an entry from the fields of a JSON or logfmt line.
+func entryFromFields(fields []Attr) (Entry, error) {
+	var entry Entry
+	seenTime := false
+	for _, field := range fields {
+		switch field.Key {
+		case FieldTime:
+			ts, err := time.Parse(time.RFC3339Nano, field.Value)
+			if err != nil {
+				return Entry{}, fmt.Errorf("%w: bad timestamp %q", ErrMalformed, field.Value)
+			}
+			entry.Time, seenTime = ts, true
+		case FieldLevel:
+			level, err := log.ParseLevel(field.Value)
+			if err != nil {
+				return Entry{}, fmt.Errorf("%w: bad level %q", ErrMalformed, field.Value)
+			}
+			entry.Level = level
+		case FieldMessage:
+			entry.Message = field.Value
+		case FieldPlugin:
+			entry.Plugin = field.Value
+		case FieldRequestID:
+			entry.RequestID = field.Value
+		case FieldCaller:
+			entry.Caller = field.Value
+		default:
+			entry.Attrs = append(entry.Attrs, field)
+		}
+	}
+	if !seenTime {
+		return Entry{}, fmt.Errorf("%w: no %s field", ErrMalformed, FieldTime)
+	}
+	return entry, nil
+}
+
+var textLevels = map[string]log.Level{
+	"DEBU": log.DebugLevel,
+	"INFO": log.InfoLevel,
+	"WARN": log.WarnLevel,
+	"ERRO": log.ErrorLevel,
+	"FATA": log.FatalLevel,
+}
+
+// parseText parses a charmbracelet/log text line:
+// "2006/01/02 15:04:05 INFO <dir/file.go:12> message key=value ...".
+func parseText(line string) (Entry, error) {
+	if len(line) < len(textTimeLayout)+1 {
+		return Entry{}, ErrMalformed
+	}
+	ts, err := time.ParseInLocation(textTimeLayout, line[:len(textTimeLayout)], time.Local)
+	if err != nil {
+		return Entry{}, ErrMalformed
+	}
+	rest := strings.TrimPrefix(line[len(textTimeLayout):], " ")
+
+	levelName, rest, _ := strings.Cut(rest, " ")
+	level, ok := textLevels[levelName]
+	if !ok {
+		return Entry{}, fmt.Errorf("%w: unknown level %q", ErrMalformed, levelName)
+	}
+	entry := Entry{Time: ts, Level: level}
+
+	if strings.HasPrefix(rest, "<") {
+		if end := strings.Index(rest, "> "); end > 0 {
+			entry.Caller, rest = rest[1:end], rest[end+2:]
+		} else if strings.HasSuffix(rest, ">") {
+			entry.Caller, rest = rest[1:len(rest)-1], ""
+		}
+	}
+
+	entry.Message, entry.Attrs = splitTextAttrs(rest)
+	for i, attr := range entry.Attrs {
+		if attr.Key == FieldRequestID {
+			entry.RequestID = attr.Value
+			entry.Attrs = append(entry.Attrs[:i], entry.Attrs[i+1:]...)
+			break
+		}
+	}
+	return entry, nil
+}
+
+// splitTextAttrs splits the message from the trailing run of key=value
+// pairs in a single pass over the line's words.
+func splitTextAttrs(rest string) (string, []Attr) {
+	start := -1
+	unterminated := false
+	for i := 0; i < len(rest); {
+		if rest[i] == ' ' {
+			i++
+			continue
+		}
+		end, ok := scanTextPair(rest, i, &unterminated)
+		if !ok {
+			start = -1
+		} else if start < 0 {
+			start = i
+		}
+		i = end
+	}
+	if start < 0 {
+		return rest, nil
+	}
+	attrs, ok := parseTextAttrs(rest[start:])
+	if !ok {
+		return rest, nil
+	}
+	return strings.TrimSuffix(rest[:start], " "), attrs
+}
+
+// scanTextPair reports whether the word at s[i:] is a key=value pair and
+// returns where it ends. Once a quoted value runs to the end of the line
+// without closing, later quoted values cannot close either, which
+// unterminated remembers so the line is scanned only once.
+func scanTextPair(s string, i int, unterminated *bool) (int, bool) {
+	j := i
+	for j < len(s) && s[j] != '=' && s[j] != ' ' && s[j] != '"' {
+		j++
+	}
+	if j == i || j == len(s) || s[j] != '=' {
+		return wordEnd(s, j), false
+	}
+	j++
+	if j < len(s) && s[j] == '"' {
+		if *unterminated {
+			return wordEnd(s, j), false
+		}
+		for j++; j < len(s); j++ {
+			if s[j] == '\\' {
+				j++
+				continue
+			}
+			if s[j] == '"' {
+				if j+1 < len(s) && s[j+1] != ' ' {
+					return wordEnd(s, j+1), false
+				}
+				return j + 1, true
+			}
+		}
+		*unterminated = true
+		return wordEnd(s, i), false
+	}
+	for ; j < len(s) && s[j] != ' '; j++ {
+		if s[j] == '"' {
+			return wordEnd(s, j), false
+		}
+	}
+	return j, true
+}
+
+func wordEnd(s string, i int) int {
+	if end := strings.IndexByte(s[i:], ' '); end >= 0 {
+		return i + end
+	}
+	return len(s)
+}
+
+// parseTextAttrs decodes a run of pairs found by scanTextPair, which has
+// already checked that every word has a key and an equals sign.
+func parseTextAttrs(s string) ([]Attr, bool) {
+	decoder := logfmt.NewDecoder(strings.NewReader(s))
+	var attrs []Attr
+	for decoder.ScanRecord() {
+		for decoder.ScanKeyval() {
+			attrs = append(attrs, Attr{Key: string(decoder.Key()), Value: string(decoder.Value())})
+		}
+	}
+	if decoder.Err() != nil || len(attrs) == 0 {
+		return nil, false
+	}
+	return attrs, true
+}
@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// t0 is the time test records are logged at.
+var t0 = time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func TestEntryRoundTrip(t *testing.T) {
+	entry := Entry{
+		Time:      t0.Add(123 * time.Millisecond),
+		Level:     log.WarnLevel,
+		Message:   "sync failed",
+		Plugin:    "hello-world",
+		RequestID: "4f2a",
+		Caller:    "hello-world/main.go:42",
+		Attrs:     []Attr{{"repo", "sdk"}, {"error", "exit status 1"}, {"empty", ""}},
+	}
+	for _, format := range []Format{FormatJSON, FormatLogfmt} {
+		line, err := EncodeEntry(format, entry)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		got, err := ParseLine(strings.TrimSuffix(string(line), "\n"))
+		if err != nil {
+			t.Fatalf("%s: failed to parse %q: %v", format, line, err)
+		}
+		if !got.Time.Equal(entry.Time) {
+			t.Errorf("%s: time %v, want %v", format, got.Time, entry.Time)
+		}
+		got.Time = entry.Time
+		if !reflect.DeepEqual(got, entry) {
+			t.Errorf("%s: parsed %+v, want %+v", format, got, entry)
+		}
+	}
+}
+
+func TestParseTextLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		level   log.Level
+		message string
+		caller  string
+		attrs   []Attr
+	}{
+		{
+			line:    "2024/10/01 12:00:00 INFO <main.go:42> synced repo=sdk",
+			level:   log.InfoLevel,
+			message: "synced",
+			caller:  "main.go:42",
+			attrs:   []Attr{{"repo", "sdk"}},
+		},
+		{
+			line:    `2024/10/01 12:00:00 ERRO <main.go:7> clone failed for a=b style text error="exit status 128" retries=3`,
+			level:   log.ErrorLevel,
+			message: "clone failed for a=b style text",
+			caller:  "main.go:7",
+			attrs:   []Attr{{"error", "exit status 128"}, {"retries", "3"}},
+		},
+		{
+			line:    `2024/10/01 12:00:00 DEBU request finished content="" outcome=success`,
+			level:   log.DebugLevel,
+			message: "request finished",
+			attrs:   []Attr{{"content", ""}, {"outcome", "success"}},
+		},
+		{
+			line:    `2024/10/01 12:00:00 WARN unterminated quote="still open`,
+			level:   log.WarnLevel,
+			message: `unterminated quote="still open`,
+		},
+	}
+	for _, tt := range tests {
+		entry, err := ParseLine(tt.line)
+		if err != nil {
+			t.Errorf("ParseLine(%q): %v", tt.line, err)
+			continue
+		}
+		if entry.Level != tt.level || entry.Message != tt.message || entry.Caller != tt.caller || !reflect.DeepEqual(entry.Attrs, tt.attrs) {
+			t.Errorf("ParseLine(%q) = %+v", tt.line, entry)
+		}
+	}
+}
+
+func TestReaderSkipsBadLines(t *testing.T) {
+	input := strings.Join([]string{
+		`{"ts":"2024-10-01T12:00:00Z","level":"info","msg":"first"}`,
+		"",
+		"not a log line",
+		"  continuation of a multi-line value",
+		`{"ts":"2024-10-01T12:00:01Z","level":"info","msg":"` + strings.Repeat("x", MaxLineSize) + `"}`,
+		`ts=2024-10-01T12:00:02Z level=error msg=last`,
+	}, "\n")
+
+	reader := NewReader(strings.NewReader(input))
+	reader.Plugin = "fallback"
+	var messages []string
+	malformed := 0
+	for {
+		entry, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, ErrMalformed) {
+			malformed++
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.Plugin != "fallback" {
+			t.Errorf("entry attributed to %q", entry.Plugin)
+		}
+		messages = append(messages, entry.Message)
+	}
+
+	if !reflect.DeepEqual(messages, []string{"first", "last"}) || malformed != 1 {
+		t.Fatalf("read %v with %d malformed lines", messages, malformed)
+	}
+	if reader.Skipped() != 1 {
+		t.Fatalf("skipped %d over-long lines, want 1", reader.Skipped())
+	}
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+var (
+	timeStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#6C7086"))
+
+	pluginStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#B4BEFE"))
+
+	keyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#6C7086"))
+
+	levelStyles = map[log.Level]lipgloss.Style{
+		log.DebugLevel: lipgloss.NewStyle().Foreground(lipgloss.Color("#6C7086")),
+		log.InfoLevel:  lipgloss.NewStyle().Foreground(lipgloss.Color("#A6E3A1")),
+		log.WarnLevel:  lipgloss.NewStyle().Foreground(lipgloss.Color("#F9E2AF")),
+		log.ErrorLevel: lipgloss.NewStyle().Foreground(lipgloss.Color("#F38BA8")).Bold(true),
+		log.FatalLevel: lipgloss.NewStyle().Foreground(lipgloss.Color("#F38BA8")).Bold(true),
+	}
+)
+
+// RenderEntry formats a record as one styled line: time, level, plugin,
+// message, request ID, attributes and caller. Styles degrade to plain text
+// when stdout is not a terminal.
+func RenderEntry(entry Entry) string {
+	var b strings.Builder
+	b.WriteString(timeStyle.Render(entry.Time.Local().Format("2006-01-02 15:04:05.000")))
+	b.WriteByte(' ')
+	level := strings.ToUpper(entry.Level.String())
+	b.WriteString(levelStyles[entry.Level].Render(padRight(level, 5)))
+	if entry.Plugin != "" {
+		b.WriteByte(' ')
+		b.WriteString(pluginStyle.Render("[" + entry.Plugin + "]"))
+	}
+	b.WriteByte(' ')
+	b.WriteString(entry.Message)
+	if entry.RequestID != "" {
+		b.WriteString(" " + keyStyle.Render(FieldRequestID+"=") + entry.RequestID)
+	}
+	for _, attr := range entry.Attrs {
+		value := attr.Value
+		if value == "" || strings.ContainsAny(value, " \"=") {
+			value = strconv.Quote(value)
+		}
+		b.WriteString(" " + keyStyle.Render(attr.Key+"=") + value)
+	}
+	if entry.Caller != "" {
+		b.WriteString(" " + timeStyle.Render("<"+entry.Caller+">"))
+	}
+	return b.String()
+}
+
+func padRight(s string, width int) string {
+	if len(s) >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-len(s))
+}
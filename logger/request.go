@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+// Request identifies the command a request-scoped logger belongs to.
+type Request struct {
+	ID            string
+	Command       string
+	PluginVersion string
+}
+
+type requestKey struct{}
+
+type requestScope struct {
+	request Request
+	logger  *slog.Logger
+}
+
+// StartRequest logs that req started and returns a context carrying a
+// logger whose records are tagged with the request ID, the command and the
+// plugin version, and a function to call with the command's result, which
+// logs its duration and outcome. Requests without an ID are given one so
+// the response carries it. The start and end records are never rate
+// limited. Inside a request that was already started, such as every command
+// RunPlugin handles with Options.LogRequests, it returns ctx and logs
+// nothing.
+func (l *RateLimitedLogger) StartRequest(ctx context.Context, req *pb.CommandRequest, version string) (context.Context, func(*pb.CommandResponse, error)) {
+	if _, ok := RequestFromContext(ctx); ok {
+		return ctx, func(*pb.CommandResponse, error) {}
+	}
+	if req.RequestId == "" {
+		req.RequestId = gsplug.NewRequestID()
+	}
+	request := Request{ID: req.RequestId, Command: req.Command, PluginVersion: version}
+
+	attrs := []slog.Attr{
+		slog.String(FieldRequestID, request.ID),
+		slog.String("command", request.Command),
+	}
+	if version != "" {
+		attrs = append(attrs, slog.String("plugin_version", version))
+	}
+	h := l.Handler().WithAttrs(attrs).(*handler)
+	lifecycle := *h
+	lifecycle.unlimited = true
+
+	started := time.Now()
+	l.logRequest(ctx, &lifecycle, slog.LevelInfo, "request started")
+
+	ctx = context.WithValue(ctx, requestKey{}, &requestScope{request: request, logger: slog.New(h)})
+	return ctx, func(response *pb.CommandResponse, err error) {
+		level, outcome := slog.LevelInfo, "success"
+		attrs := []slog.Attr{slog.Duration("duration", time.Since(started))}
+		switch {
+		case err != nil:
+			level, outcome = slog.LevelError, "error"
+			attrs = append(attrs, slog.String("error", err.Error()))
+		case response == nil || !response.Success:
+			level, outcome = slog.LevelWarn, "failure"
+			if response != nil && response.ErrorMessage != "" {
+				attrs = append(attrs, slog.String("error", response.ErrorMessage))
+			}
+		}
+		attrs = append(attrs, slog.String("outcome", outcome))
+		l.logRequest(ctx, &lifecycle, level, "request finished", attrs...)
+	}
+}
+
+func (l *RateLimitedLogger) logRequest(ctx context.Context, h *handler, level slog.Level, message string, attrs ...slog.Attr) {
+	if !l.Enabled(level) {
+		return
+	}
+	record := slog.NewRecord(time.Now(), level, message, 0)
+	record.AddAttrs(attrs...)
+	l.handle(ctx, record, h)
+}
+
+// Middleware returns router middleware that runs each command inside
+// StartRequest, so commands added with CommandContext can log through
+// FromContext. It is only needed when Options.LogRequests is off.
+func (l *RateLimitedLogger) Middleware(version string) gsplug.Middleware {
+	return func(next gsplug.ContextCommandFunc) gsplug.ContextCommandFunc {
+		return func(ctx context.Context, req *pb.CommandRequest) (*pb.CommandResponse, error) {
+			ctx, done := l.StartRequest(ctx, req, version)
+			response, err := next(ctx, req)
+			done(response, err)
+			return response, err
+		}
+	}
+}
+
+// FromContext returns the request-scoped logger StartRequest attached to
+// ctx, or slog.Default() outside a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if scope, ok := ctx.Value(requestKey{}).(*requestScope); ok {
+		return scope.logger
+	}
+	return slog.Default()
+}
+
+// RequestFromContext returns the request StartRequest attached to ctx.
+func RequestFromContext(ctx context.Context) (Request, bool) {
+	scope, ok := ctx.Value(requestKey{}).(*requestScope)
+	if !ok {
+		return Request{}, false
+	}
+	return scope.request, true
+}
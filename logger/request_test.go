@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ssotops/gitspace-plugin-sdk/gsplug"
+	pb "github.com/ssotops/gitspace-plugin-sdk/proto"
+)
+
+// plainPlugin is a PluginHandler without a router that logs each command
+// through l.
+type plainPlugin struct {
+	l *RateLimitedLogger
+}
+
+func (p plainPlugin) GetPluginInfo(*pb.PluginInfoRequest) (*pb.PluginInfo, error) {
+	return &pb.PluginInfo{Name: "plain", Version: "1.0.0"}, nil
+}
+
+func (p plainPlugin) ExecuteCommand(req *pb.CommandRequest) (*pb.CommandResponse, error) {
+	p.l.Info("handling")
+	return &pb.CommandResponse{Success: true}, nil
+}
+
+func (p plainPlugin) GetMenu(*pb.MenuRequest) (*pb.MenuResponse, error) {
+	return &pb.MenuResponse{}, nil
+}
+
+// routedPlugin runs its commands on a router that also uses the logger's
+// middleware.
+type routedPlugin struct {
+	plainPlugin
+	*gsplug.CommandRouter
+}
+
+func (p routedPlugin) ExecuteCommand(req *pb.CommandRequest) (*pb.CommandResponse, error) {
+	return p.CommandRouter.ExecuteCommand(req)
+}
+
+func (p routedPlugin) GetMenu(req *pb.MenuRequest) (*pb.MenuResponse, error) {
+	return p.CommandRouter.GetMenu(req)
+}
+
+func TestRequestsLoggedAutomatically(t *testing.T) {
+	l, sink := newTestLogger(t, Options{LogRequests: true})
+
+	router := gsplug.NewCommandRouter()
+	router.Use(l.Middleware("1.0.0"))
+	router.CommandContext("Routed", "routed", func(ctx context.Context, req *pb.CommandRequest) (*pb.CommandResponse, error) {
+		if request, ok := RequestFromContext(ctx); !ok || request.ID != req.RequestId || request.PluginVersion != "1.0.0" {
+			t.Errorf("command ran in request %+v, %v", request, ok)
+		}
+		FromContext(ctx).Info("handling")
+		return &pb.CommandResponse{Success: true}, nil
+	})
+
+	tests := []struct {
+		name    string
+		handler gsplug.PluginHandler
+		command string
+	}{
+		{"plain handler", plainPlugin{l}, "plain"},
+		// The middleware finds the request already started and logs nothing.
+		{"router with middleware", routedPlugin{plainPlugin{l}, router}, "routed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink.mu.Lock()
+			sink.records = nil
+			sink.mu.Unlock()
+
+			if _, err := gsplug.HandleMessage(tt.handler, gsplug.MessageTypePluginInfo, &pb.PluginInfoRequest{}); err != nil {
+				t.Fatal(err)
+			}
+			msg, err := gsplug.HandleMessage(tt.handler, gsplug.MessageTypeCommand, &pb.CommandRequest{Command: tt.command})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msg.(*pb.CommandResponse).RequestId == "" {
+				t.Error("response carries no request ID")
+			}
+			want := []string{"request started", "handling", "request finished"}
+			if got := sink.messages(); !reflect.DeepEqual(got, want) {
+				t.Fatalf("logged %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestRequestLoggingStopsOnClose(t *testing.T) {
+	l, sink := newTestLogger(t, Options{LogRequests: true})
+	l.Close()
+
+	if _, err := gsplug.HandleMessage(plainPlugin{l}, gsplug.MessageTypeCommand, &pb.CommandRequest{Command: "plain"}); err != nil {
+		t.Fatal(err)
+	}
+	if messages := sink.messages(); len(messages) != 0 {
+		t.Fatalf("closed logger logged %q", messages)
+	}
+}
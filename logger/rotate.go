@@ -0,0 +1,235 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatingFile writes a plugin's log to <name>_<date>_<NN>.log in dir,
+// moving on to the next index when the file reaches MaxFileSize.
+type rotatingFile struct {
+	dir  string
+	name string
+	opts Options
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	total  int64
+	files  []string
+	closed bool
+}
+
+func openRotatingFile(dir, name string, opts Options) (*rotatingFile, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	r := &rotatingFile{dir: dir, name: name, opts: opts}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	r.prune()
+	return r, nil
+}
+
+// open creates the log file after the highest index used today, so names
+// sort chronologically even after older files were pruned.
+func (r *rotatingFile) open() error {
+	date := time.Now().Format("20060102")
+	prefix := fmt.Sprintf("%s_%s_", r.name, date)
+	index := 0
+	if entries, err := os.ReadDir(r.dir); err == nil {
+		for _, entry := range entries {
+			var n int
+			rest, ok := strings.CutPrefix(entry.Name(), prefix)
+			if ok && len(rest) >= 2 {
+				if _, err := fmt.Sscanf(rest, "%d", &n); err == nil && n >= index {
+					index = n + 1
+				}
+			}
+		}
+	}
+
+	for ; ; index++ {
+		path := filepath.Join(r.dir, fmt.Sprintf("%s%02d.log", prefix, index))
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create log file: %w", err)
+		}
+		r.file, r.size = file, 0
+		return nil
+	}
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+	if r.opts.MaxFileSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.opts.MaxFileSize {
+		r.rotate()
+	}
+	if r.size == 0 {
+		r.files = append(r.files, r.file.Name())
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	r.total += int64(n)
+	return n, err
+}
+
+// rotate closes the current file and opens the next one. When the next file
+// cannot be created the current one keeps growing rather than losing logs.
+func (r *rotatingFile) rotate() {
+	previous := r.file
+	if err := r.open(); err != nil {
+		return
+	}
+	previous.Close()
+	if r.opts.Compress {
+		if err := compressFile(previous.Name()); err == nil {
+			r.renameFile(previous.Name(), previous.Name()+".gz")
+		}
+	}
+	r.prune()
+}
+
+func (r *rotatingFile) renameFile(from, to string) {
+	for i, file := range r.files {
+		if file == from {
+			r.files[i] = to
+		}
+	}
+}
+
+// Name returns the path of the file being written.
+func (r *rotatingFile) Name() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Name()
+}
+
+// Files returns the files written to so far, oldest first.
+func (r *rotatingFile) Files() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.files...)
+}
+
+// BytesWritten returns the bytes written across all files.
+func (r *rotatingFile) BytesWritten() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.total
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if err := r.file.Sync(); err != nil {
+		r.file.Close()
+		return fmt.Errorf("failed to flush log file: %w", err)
+	}
+	return r.file.Close()
+}
+
+type logFileInfo struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// prune deletes the oldest of the plugin's log files until the directory is
+// within MaxFiles, MaxAge and MaxTotalSize. The current file is always kept.
+func (r *rotatingFile) prune() {
+	if r.opts.MaxFiles == 0 && r.opts.MaxAge == 0 && r.opts.MaxTotalSize == 0 {
+		return
+	}
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return
+	}
+
+	var files []logFileInfo
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, r.name+"_") || !(strings.HasSuffix(name, ".log") || strings.HasSuffix(name, ".log.gz")) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, logFileInfo{path: filepath.Join(r.dir, name), size: info.Size(), modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+
+	current := r.file.Name()
+	kept, total := 1, r.size
+	for _, file := range files {
+		if file.path == current {
+			continue
+		}
+		expired := r.opts.MaxAge > 0 && time.Since(file.modTime) > r.opts.MaxAge
+		tooMany := r.opts.MaxFiles > 0 && kept >= r.opts.MaxFiles
+		tooBig := r.opts.MaxTotalSize > 0 && total+file.size > r.opts.MaxTotalSize
+		if expired || tooMany || tooBig {
+			os.Remove(file.path)
+			continue
+		}
+		kept++
+		total += file.size
+	}
+}
+
+// compressFile replaces path with path.gz.
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := path + ".gz.tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(path)
+}
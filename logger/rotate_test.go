@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeLines(t *testing.T, file *rotatingFile, from, to int) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		line := fmt.Sprintf(`{"ts":"2024-10-01T12:00:%02dZ","level":"info","msg":"line %02d"}`+"\n", i, i)
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readLines returns the lines of a log file, decompressing .gz files.
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestRotationCompressesOldFiles(t *testing.T) {
+	dir := t.TempDir()
+	file, err := openRotatingFile(dir, "rot", Options{MaxFileSize: 150, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each line is 61 bytes, so every file holds two.
+	writeLines(t, file, 0, 5)
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files := file.Files()
+	if len(files) != 3 {
+		t.Fatalf("wrote %d files, want 3: %v", len(files), files)
+	}
+	var messages []string
+	for i, path := range files {
+		if compressed := strings.HasSuffix(path, ".log.gz"); compressed != (i < len(files)-1) {
+			t.Errorf("%s: compressed %v", path, compressed)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("listed file is missing: %v", err)
+		}
+		for _, line := range readLines(t, path) {
+			var record struct {
+				Message string `json:"msg"`
+			}
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+			messages = append(messages, record.Message)
+		}
+	}
+	if got := strings.Join(messages, ","); got != "line 00,line 01,line 02,line 03,line 04" {
+		t.Fatalf("read back %s", got)
+	}
+	if file.BytesWritten() != 5*61 {
+		t.Fatalf("BytesWritten = %d", file.BytesWritten())
+	}
+}
+
+func TestRotationPrunes(t *testing.T) {
+	dir := t.TempDir()
+	unrelated := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(unrelated, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := openRotatingFile(dir, "rot", Options{MaxFileSize: 100, MaxFiles: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLines(t, file, 0, 6)
+	file.Close()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logs []string
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".log") {
+			logs = append(logs, entry.Name())
+		}
+	}
+	if len(logs) != 2 {
+		t.Fatalf("kept %v, want the 2 newest files", logs)
+	}
+	current := filepath.Base(file.Name())
+	if logs[1] != current {
+		t.Fatalf("kept %v without the current file %s", logs, current)
+	}
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Fatalf("pruning removed an unrelated file: %v", err)
+	}
+
+	// A new session continues the numbering after the newest file.
+	next, err := openRotatingFile(dir, "rot", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer next.Close()
+	if next.Name() <= file.Name() {
+		t.Fatalf("new session writes %s, which sorts before %s", next.Name(), file.Name())
+	}
+}
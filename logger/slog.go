@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/charmbracelet/log"
+)
+
+// Handler returns a slog.Handler that logs through l: records are
+// redacted, rate limited and written to the log file and sinks. Attributes
+// and groups added with WithAttrs and WithGroup are passed on to the sinks.
+func (l *RateLimitedLogger) Handler() slog.Handler {
+	return &handler{logger: l, sinks: l.sinks}
+}
+
+// Slog returns a *slog.Logger using Handler.
+func (l *RateLimitedLogger) Slog() *slog.Logger {
+	return slog.New(l.Handler())
+}
+
+type handler struct {
+	logger *RateLimitedLogger
+	sinks  []slog.Handler
+	// keyvals are the attributes added with WithAttrs, flattened with their
+	// group prefix, for rate limiting.
+	keyvals []interface{}
+	prefix  string
+	// unlimited records bypass the rate limiter.
+	unlimited bool
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.logger.Enabled(level)
+}
+
+func (h *handler) Handle(ctx context.Context, record slog.Record) error {
+	return h.logger.handle(ctx, record, h)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = redactAttr(attr)
+	}
+	clone := h.derive(func(sink slog.Handler) slog.Handler { return sink.WithAttrs(redacted) })
+	clone.keyvals = appendFlattened(append([]interface{}(nil), h.keyvals...), h.prefix, redacted)
+	return clone
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := h.derive(func(sink slog.Handler) slog.Handler { return sink.WithGroup(name) })
+	clone.prefix = h.prefix + name + "."
+	return clone
+}
+
+func (h *handler) derive(fn func(slog.Handler) slog.Handler) *handler {
+	sinks := make([]slog.Handler, len(h.sinks))
+	for i, sink := range h.sinks {
+		sinks[i] = fn(sink)
+	}
+	return &handler{logger: h.logger, sinks: sinks, keyvals: h.keyvals, prefix: h.prefix, unlimited: h.unlimited}
+}
+
+// limiterRecord flattens a record and the handler's attributes for the
+// rate limiter.
+func (h *handler) limiterRecord(record slog.Record) Record {
+	keyvals := append([]interface{}(nil), h.keyvals...)
+	record.Attrs(func(attr slog.Attr) bool {
+		keyvals = appendFlattened(keyvals, h.prefix, []slog.Attr{attr})
+		return true
+	})
+	return Record{
+		Time:    record.Time,
+		Level:   log.Level(record.Level),
+		Message: record.Message,
+		Keyvals: keyvals,
+	}
+}
+
+// appendFlattened appends attrs as key/value pairs, naming group members
+// "group.key".
+func appendFlattened(keyvals []interface{}, prefix string, attrs []slog.Attr) []interface{} {
+	for _, attr := range attrs {
+		value := attr.Value.Resolve()
+		if value.Kind() == slog.KindGroup {
+			groupPrefix := prefix
+			if attr.Key != "" {
+				groupPrefix = prefix + attr.Key + "."
+			}
+			keyvals = appendFlattened(keyvals, groupPrefix, value.Group())
+			continue
+		}
+		keyvals = append(keyvals, fmt.Sprint(prefix, attr.Key), value.Any())
+	}
+	return keyvals
+}
@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Stats describes what a RateLimitedLogger wrote during its session.
+type Stats struct {
+	Plugin string   `json:"plugin"`
+	Files  []string `json:"files"`
+	// Counts holds the records written per level name.
+	Counts map[string]int `json:"counts"`
+	// FirstError and LastError are the first and last error records.
+	FirstError *ErrorRecord `json:"first_error,omitempty"`
+	LastError  *ErrorRecord `json:"last_error,omitempty"`
+	// Suppressed counts the records the rate limiter dropped.
+	Suppressed int `json:"suppressed"`
+	// Dropped counts records that could not be forwarded to the host.
+	Dropped int64 `json:"dropped,omitempty"`
+	// Bytes is the size of everything written to the log files.
+	Bytes    int64         `json:"bytes"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// ErrorRecord is an error message and when it was logged.
+type ErrorRecord struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// Total returns the number of records written.
+func (s Stats) Total() int {
+	total := 0
+	for _, count := range s.Counts {
+		total += count
+	}
+	return total
+}
+
+// sessionStats is updated under the logger's lock.
+type sessionStats struct {
+	plugin     string
+	started    time.Time
+	ended      time.Time
+	counts     map[log.Level]int
+	firstError *ErrorRecord
+	lastError  *ErrorRecord
+	suppressed int
+}
+
+func (s *sessionStats) record(record slog.Record) {
+	if s.counts == nil {
+		s.counts = make(map[log.Level]int)
+	}
+	level := levelOf(record.Level)
+	s.counts[level]++
+	if level >= log.ErrorLevel {
+		s.lastError = &ErrorRecord{Time: record.Time, Message: record.Message}
+		if s.firstError == nil {
+			s.firstError = s.lastError
+		}
+	}
+}
+
+// Stats returns counts and sizes for the session so far, or up to Close.
+func (l *RateLimitedLogger) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ended := l.stats.ended
+	if ended.IsZero() {
+		ended = time.Now()
+	}
+	stats := Stats{
+		Plugin:     l.stats.plugin,
+		Files:      l.file.Files(),
+		Counts:     make(map[string]int, len(l.stats.counts)),
+		FirstError: l.stats.firstError,
+		LastError:  l.stats.lastError,
+		Suppressed: l.stats.suppressed,
+		Bytes:      l.file.BytesWritten(),
+		Started:    l.stats.started,
+		Duration:   ended.Sub(l.stats.started),
+	}
+	for level, count := range l.stats.counts {
+		stats.Counts[level.String()] = count
+	}
+	if l.forwarder != nil {
+		stats.Dropped = l.forwarder.dropped.Load()
+	}
+	return stats
+}
@@ -1,10 +1,16 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/mattn/go-isatty"
 )
 
 var (
@@ -27,25 +33,125 @@ var (
 			Padding(1)
 )
 
+// SummaryFormat selects how WriteLogSummary renders.
+type SummaryFormat string
+
+const (
+	// SummaryAuto renders styled output on a terminal and plain text
+	// otherwise.
+	SummaryAuto   SummaryFormat = ""
+	SummaryStyled SummaryFormat = "styled"
+	SummaryPlain  SummaryFormat = "plain"
+	// SummaryJSON writes {"loggers": [Stats, ...]} for automation.
+	SummaryJSON SummaryFormat = "json"
+)
+
+type SummaryOptions struct {
+	Format SummaryFormat
+}
+
+// PrintLogSummary writes the end-of-session summary of loggers to stdout.
 func PrintLogSummary(loggers []*RateLimitedLogger) {
-	var allUpdatedFiles []string
-	for _, logger := range loggers {
-		allUpdatedFiles = append(allUpdatedFiles, logger.GetUpdatedLogFiles()...)
+	WriteLogSummary(os.Stdout, loggers, SummaryOptions{})
+}
+
+// WriteLogSummary writes, for each logger, the files it updated, its record
+// counts by level, its first and last errors, how many records were
+// suppressed, the bytes written and how long the session lasted.
+func WriteLogSummary(w io.Writer, loggers []*RateLimitedLogger, opts SummaryOptions) error {
+	stats := make([]Stats, len(loggers))
+	for i, logger := range loggers {
+		stats[i] = logger.Stats()
 	}
 
-	if len(allUpdatedFiles) == 0 {
-		fmt.Println(subtitleStyle.Render("No logs were updated during this session."))
-		return
+	format := opts.Format
+	if format == SummaryAuto {
+		format = SummaryPlain
+		if file, ok := w.(*os.File); ok && isatty.IsTerminal(file.Fd()) {
+			format = SummaryStyled
+		}
 	}
 
-	summary := strings.Builder{}
-	summary.WriteString(titleStyle.Render("Log Summary") + "\n\n")
-	summary.WriteString(subtitleStyle.Render("The following log files were updated:") + "\n\n")
+	if format == SummaryJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Loggers []Stats `json:"loggers"`
+		}{stats})
+	}
 
-	for _, file := range allUpdatedFiles {
-		// Use the full path instead of just the filename
-		summary.WriteString(logFileStyle.Render("  • "+file) + "\n")
+	styled := format == SummaryStyled
+	render := func(style lipgloss.Style, s string) string {
+		if styled {
+			return style.Render(s)
+		}
+		return s
+	}
+
+	var updated []Stats
+	for _, s := range stats {
+		if len(s.Files) > 0 {
+			updated = append(updated, s)
+		}
+	}
+	if len(updated) == 0 {
+		_, err := fmt.Fprintln(w, render(subtitleStyle, "No logs were updated during this session."))
+		return err
 	}
 
-	fmt.Println(borderStyle.Render(summary.String()))
+	var summary strings.Builder
+	summary.WriteString(render(titleStyle, "Log Summary") + "\n")
+	for _, s := range updated {
+		summary.WriteString("\n")
+		summary.WriteString(render(subtitleStyle, fmt.Sprintf("%s: %s written in %s",
+			s.Plugin, formatBytes(s.Bytes), s.Duration.Round(time.Millisecond))) + "\n")
+		for _, file := range s.Files {
+			summary.WriteString(render(logFileStyle, "  • "+file) + "\n")
+		}
+
+		var counts []string
+		for _, level := range []log.Level{log.DebugLevel, log.InfoLevel, log.WarnLevel, log.ErrorLevel, log.FatalLevel} {
+			if count := s.Counts[level.String()]; count > 0 {
+				counts = append(counts, render(levelStyles[level], fmt.Sprintf("%s %d", level, count)))
+			}
+		}
+		if s.Suppressed > 0 {
+			counts = append(counts, fmt.Sprintf("suppressed %d", s.Suppressed))
+		}
+		if s.Dropped > 0 {
+			counts = append(counts, fmt.Sprintf("not forwarded %d", s.Dropped))
+		}
+		if len(counts) > 0 {
+			summary.WriteString("  " + strings.Join(counts, ", ") + "\n")
+		}
+
+		if s.FirstError != nil {
+			summary.WriteString(render(levelStyles[log.ErrorLevel], "  first error: ") + describeError(s.FirstError) + "\n")
+		}
+		if s.LastError != nil && s.LastError != s.FirstError {
+			summary.WriteString(render(levelStyles[log.ErrorLevel], "  last error:  ") + describeError(s.LastError) + "\n")
+		}
+	}
+
+	out := strings.TrimSuffix(summary.String(), "\n")
+	if styled {
+		out = borderStyle.Render(out)
+	}
+	_, err := fmt.Fprintln(w, out)
+	return err
+}
+
+func describeError(e *ErrorRecord) string {
+	return e.Time.Local().Format("15:04:05") + " " + e.Message
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
 }
@@ -19,9 +19,14 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	PluginService_GetPluginInfo_FullMethodName  = "/gitspace.plugin.PluginService/GetPluginInfo"
-	PluginService_ExecuteCommand_FullMethodName = "/gitspace.plugin.PluginService/ExecuteCommand"
-	PluginService_GetMenu_FullMethodName        = "/gitspace.plugin.PluginService/GetMenu"
+	PluginService_GetPluginInfo_FullMethodName    = "/gitspace.plugin.PluginService/GetPluginInfo"
+	PluginService_ExecuteCommand_FullMethodName   = "/gitspace.plugin.PluginService/ExecuteCommand"
+	PluginService_GetMenu_FullMethodName          = "/gitspace.plugin.PluginService/GetMenu"
+	PluginService_GetSubscriptions_FullMethodName = "/gitspace.plugin.PluginService/GetSubscriptions"
+	PluginService_HandleEvent_FullMethodName      = "/gitspace.plugin.PluginService/HandleEvent"
+	PluginService_GetConfig_FullMethodName        = "/gitspace.plugin.PluginService/GetConfig"
+	PluginService_UpdateConfig_FullMethodName     = "/gitspace.plugin.PluginService/UpdateConfig"
+	PluginService_SetSecrets_FullMethodName       = "/gitspace.plugin.PluginService/SetSecrets"
 )
 
 // PluginServiceClient is the client API for PluginService service.
@@ -31,6 +36,11 @@ type PluginServiceClient interface {
 	GetPluginInfo(ctx context.Context, in *PluginInfoRequest, opts ...grpc.CallOption) (*PluginInfo, error)
 	ExecuteCommand(ctx context.Context, in *CommandRequest, opts ...grpc.CallOption) (*CommandResponse, error)
 	GetMenu(ctx context.Context, in *MenuRequest, opts ...grpc.CallOption) (*MenuResponse, error)
+	GetSubscriptions(ctx context.Context, in *SubscriptionRequest, opts ...grpc.CallOption) (*SubscriptionResponse, error)
+	HandleEvent(ctx context.Context, in *Event, opts ...grpc.CallOption) (*EventResponse, error)
+	GetConfig(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (*ConfigResponse, error)
+	UpdateConfig(ctx context.Context, in *UpdateConfigRequest, opts ...grpc.CallOption) (*UpdateConfigResponse, error)
+	SetSecrets(ctx context.Context, in *SecretsRequest, opts ...grpc.CallOption) (*SecretsResponse, error)
 }
 
 type pluginServiceClient struct {
@@ -71,6 +81,56 @@ func (c *pluginServiceClient) GetMenu(ctx context.Context, in *MenuRequest, opts
 	return out, nil
 }
 
+func (c *pluginServiceClient) GetSubscriptions(ctx context.Context, in *SubscriptionRequest, opts ...grpc.CallOption) (*SubscriptionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubscriptionResponse)
+	err := c.cc.Invoke(ctx, PluginService_GetSubscriptions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginServiceClient) HandleEvent(ctx context.Context, in *Event, opts ...grpc.CallOption) (*EventResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EventResponse)
+	err := c.cc.Invoke(ctx, PluginService_HandleEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginServiceClient) GetConfig(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (*ConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConfigResponse)
+	err := c.cc.Invoke(ctx, PluginService_GetConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginServiceClient) UpdateConfig(ctx context.Context, in *UpdateConfigRequest, opts ...grpc.CallOption) (*UpdateConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateConfigResponse)
+	err := c.cc.Invoke(ctx, PluginService_UpdateConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginServiceClient) SetSecrets(ctx context.Context, in *SecretsRequest, opts ...grpc.CallOption) (*SecretsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SecretsResponse)
+	err := c.cc.Invoke(ctx, PluginService_SetSecrets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PluginServiceServer is the server API for PluginService service.
 // All implementations must embed UnimplementedPluginServiceServer
 // for forward compatibility.
@@ -78,6 +138,11 @@ type PluginServiceServer interface {
 	GetPluginInfo(context.Context, *PluginInfoRequest) (*PluginInfo, error)
 	ExecuteCommand(context.Context, *CommandRequest) (*CommandResponse, error)
 	GetMenu(context.Context, *MenuRequest) (*MenuResponse, error)
+	GetSubscriptions(context.Context, *SubscriptionRequest) (*SubscriptionResponse, error)
+	HandleEvent(context.Context, *Event) (*EventResponse, error)
+	GetConfig(context.Context, *ConfigRequest) (*ConfigResponse, error)
+	UpdateConfig(context.Context, *UpdateConfigRequest) (*UpdateConfigResponse, error)
+	SetSecrets(context.Context, *SecretsRequest) (*SecretsResponse, error)
 	mustEmbedUnimplementedPluginServiceServer()
 }
 
@@ -97,6 +162,21 @@ func (UnimplementedPluginServiceServer) ExecuteCommand(context.Context, *Command
 func (UnimplementedPluginServiceServer) GetMenu(context.Context, *MenuRequest) (*MenuResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetMenu not implemented")
 }
+func (UnimplementedPluginServiceServer) GetSubscriptions(context.Context, *SubscriptionRequest) (*SubscriptionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSubscriptions not implemented")
+}
+func (UnimplementedPluginServiceServer) HandleEvent(context.Context, *Event) (*EventResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HandleEvent not implemented")
+}
+func (UnimplementedPluginServiceServer) GetConfig(context.Context, *ConfigRequest) (*ConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConfig not implemented")
+}
+func (UnimplementedPluginServiceServer) UpdateConfig(context.Context, *UpdateConfigRequest) (*UpdateConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateConfig not implemented")
+}
+func (UnimplementedPluginServiceServer) SetSecrets(context.Context, *SecretsRequest) (*SecretsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetSecrets not implemented")
+}
 func (UnimplementedPluginServiceServer) mustEmbedUnimplementedPluginServiceServer() {}
 func (UnimplementedPluginServiceServer) testEmbeddedByValue()                       {}
 
@@ -172,6 +252,96 @@ func _PluginService_GetMenu_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PluginService_GetSubscriptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServiceServer).GetSubscriptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PluginService_GetSubscriptions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServiceServer).GetSubscriptions(ctx, req.(*SubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PluginService_HandleEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Event)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServiceServer).HandleEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PluginService_HandleEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServiceServer).HandleEvent(ctx, req.(*Event))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PluginService_GetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServiceServer).GetConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PluginService_GetConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServiceServer).GetConfig(ctx, req.(*ConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PluginService_UpdateConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServiceServer).UpdateConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PluginService_UpdateConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServiceServer).UpdateConfig(ctx, req.(*UpdateConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PluginService_SetSecrets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SecretsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServiceServer).SetSecrets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PluginService_SetSecrets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServiceServer).SetSecrets(ctx, req.(*SecretsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PluginService_ServiceDesc is the grpc.ServiceDesc for PluginService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -191,6 +361,26 @@ var PluginService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetMenu",
 			Handler:    _PluginService_GetMenu_Handler,
 		},
+		{
+			MethodName: "GetSubscriptions",
+			Handler:    _PluginService_GetSubscriptions_Handler,
+		},
+		{
+			MethodName: "HandleEvent",
+			Handler:    _PluginService_HandleEvent_Handler,
+		},
+		{
+			MethodName: "GetConfig",
+			Handler:    _PluginService_GetConfig_Handler,
+		},
+		{
+			MethodName: "UpdateConfig",
+			Handler:    _PluginService_UpdateConfig_Handler,
+		},
+		{
+			MethodName: "SetSecrets",
+			Handler:    _PluginService_SetSecrets_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/plugin.proto",